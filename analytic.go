@@ -0,0 +1,383 @@
+package main
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Parameters for the Morse potential. The bends are harmonic in the
+// cosine of each angle, which keeps them smooth through linear
+// geometries, and prefer the tetrahedral angle
+const (
+	morseDepth  = 0.2 // well depth in Hartrees
+	morseWidth  = 2.0 // in inverse Angstroms
+	morseRadius = 0.75
+	morseBend   = 0.1 // bend force constant in Hartrees
+	morseCos    = -1.0 / 3
+)
+
+// covalentRadii gives the covalent radius in Angstroms of the atoms
+// used for the Morse equilibrium distances. Anything else uses
+// morseRadius
+var covalentRadii = map[string]float64{
+	"H": 0.31,
+	"C": 0.76,
+	"N": 0.71,
+	"O": 0.66,
+	"F": 0.57,
+}
+
+// analyticPotential is the potential evaluated by Analytic, taking
+// names and coordinates in Angstroms and returning an energy in
+// Hartrees. analyticGradient and analyticHessian are its gradient and
+// flattened Hessian, in Hartree/Angstrom and Hartree/Angstrom^2
+var (
+	analyticPotential = Morse
+	analyticGradient  = MorseGradient
+	analyticHessian   = MorseHessian
+)
+
+// covalentRadius returns the covalent radius of the atom name, or
+// morseRadius if it is not in covalentRadii
+func covalentRadius(name string) float64 {
+	if r, ok := covalentRadii[name]; ok {
+		return r
+	}
+	return morseRadius
+}
+
+// bendCoeffs gives the derivatives of the two arms j-i and k-i of an
+// angle with respect to atoms i, j, and k
+var bendCoeffs = [3][2]float64{{-1, -1}, {1, 0}, {0, 1}}
+
+// bendCos returns the cosine c of the angle j-i-k along with its
+// gradient and Hessian blocks with respect to the positions of i, j,
+// and k, in that order
+func bendCos(coords []float64, i, j, k int) (c float64, g [3][3]float64,
+	h [3][3][3][3]float64) {
+	var (
+		arms [2][3]float64 // unit vectors along j-i and k-i
+		lens [2]float64
+	)
+	for a, p := range [2]int{j, k} {
+		for x := 0; x < 3; x++ {
+			arms[a][x] = coords[3*p+x] - coords[3*i+x]
+			lens[a] += arms[a][x] * arms[a][x]
+		}
+		lens[a] = math.Sqrt(lens[a])
+		for x := range arms[a] {
+			arms[a][x] /= lens[a]
+		}
+	}
+	for x := 0; x < 3; x++ {
+		c += arms[0][x] * arms[1][x]
+	}
+	// derivatives with respect to the arms themselves
+	var (
+		ga [2][3]float64
+		ha [2][2][3][3]float64
+	)
+	for a := 0; a < 2; a++ {
+		b := 1 - a
+		for x := 0; x < 3; x++ {
+			ga[a][x] = (arms[b][x] - c*arms[a][x]) / lens[a]
+		}
+	}
+	for x := 0; x < 3; x++ {
+		for y := 0; y < 3; y++ {
+			var delta float64
+			if x == y {
+				delta = 1
+			}
+			for a := 0; a < 2; a++ {
+				u, v := arms[a], arms[1-a]
+				ha[a][a][x][y] = -(v[x]*u[y] + u[x]*v[y] +
+					c*(delta-3*u[x]*u[y])) / (lens[a] * lens[a])
+			}
+			u, v := arms[0], arms[1]
+			ha[0][1][x][y] = (delta - v[x]*v[y] - u[x]*u[y] +
+				c*u[x]*v[y]) / (lens[0] * lens[1])
+			ha[1][0][y][x] = ha[0][1][x][y]
+		}
+	}
+	for p := 0; p < 3; p++ {
+		for a := 0; a < 2; a++ {
+			for x := 0; x < 3; x++ {
+				g[p][x] += bendCoeffs[p][a] * ga[a][x]
+			}
+		}
+		for q := 0; q < 3; q++ {
+			for a := 0; a < 2; a++ {
+				for b := 0; b < 2; b++ {
+					f := bendCoeffs[p][a] * bendCoeffs[q][b]
+					if f == 0 {
+						continue
+					}
+					for x := 0; x < 3; x++ {
+						for y := 0; y < 3; y++ {
+							h[p][q][x][y] += f * ha[a][b][x][y]
+						}
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+// forBends calls f with the vertex and arms of every angle between
+// three of the n atoms
+func forBends(n int, f func(i, j, k int)) {
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			for k := j + 1; k < n; k++ {
+				if j != i && k != i {
+					f(i, j, k)
+				}
+			}
+		}
+	}
+}
+
+// Morse returns the sum of Morse stretches between every pair of
+// atoms, with equilibrium distances given by the sum of their
+// covalent radii, and harmonic bends for every angle between them
+func Morse(names []string, coords []float64) (energy float64) {
+	for i := range names {
+		for j := i + 1; j < len(names); j++ {
+			var r float64
+			for k := 0; k < 3; k++ {
+				d := coords[3*i+k] - coords[3*j+k]
+				r += d * d
+			}
+			r = math.Sqrt(r)
+			re := covalentRadius(names[i]) + covalentRadius(names[j])
+			x := 1 - math.Exp(-morseWidth*(r-re))
+			energy += morseDepth * x * x
+		}
+	}
+	forBends(len(names), func(i, j, k int) {
+		c, _, _ := bendCos(coords, i, j, k)
+		energy += morseBend / 2 * (c - morseCos) * (c - morseCos)
+	})
+	return
+}
+
+// MorseGradient returns the gradient of Morse
+func MorseGradient(names []string, coords []float64) []float64 {
+	grad := make([]float64, len(coords))
+	for i := range names {
+		for j := i + 1; j < len(names); j++ {
+			var r float64
+			for k := 0; k < 3; k++ {
+				d := coords[3*i+k] - coords[3*j+k]
+				r += d * d
+			}
+			r = math.Sqrt(r)
+			re := covalentRadius(names[i]) + covalentRadius(names[j])
+			e := math.Exp(-morseWidth * (r - re))
+			dEdr := 2 * morseDepth * (1 - e) * morseWidth * e
+			for k := 0; k < 3; k++ {
+				d := (coords[3*i+k] - coords[3*j+k]) / r
+				grad[3*i+k] += dEdr * d
+				grad[3*j+k] -= dEdr * d
+			}
+		}
+	}
+	forBends(len(names), func(i, j, k int) {
+		c, g, _ := bendCos(coords, i, j, k)
+		for p, atom := range [3]int{i, j, k} {
+			for x := 0; x < 3; x++ {
+				grad[3*atom+x] += morseBend * (c - morseCos) * g[p][x]
+			}
+		}
+	})
+	return grad
+}
+
+// MorseHessian returns the Hessian of Morse, flattened by rows
+func MorseHessian(names []string, coords []float64) []float64 {
+	n := len(coords)
+	hess := make([]float64, n*n)
+	for i := range names {
+		for j := i + 1; j < len(names); j++ {
+			var (
+				r float64
+				u [3]float64
+			)
+			for k := 0; k < 3; k++ {
+				u[k] = coords[3*i+k] - coords[3*j+k]
+				r += u[k] * u[k]
+			}
+			r = math.Sqrt(r)
+			for k := range u {
+				u[k] /= r
+			}
+			re := covalentRadius(names[i]) + covalentRadius(names[j])
+			e := math.Exp(-morseWidth * (r - re))
+			dEdr := 2 * morseDepth * (1 - e) * morseWidth * e
+			d2Edr2 := 2 * morseDepth * morseWidth * morseWidth * e * (2*e - 1)
+			for k := 0; k < 3; k++ {
+				for l := 0; l < 3; l++ {
+					b := d2Edr2 * u[k] * u[l]
+					if k == l {
+						b += dEdr / r * (1 - u[k]*u[l])
+					} else {
+						b -= dEdr / r * u[k] * u[l]
+					}
+					hess[(3*i+k)*n+3*i+l] += b
+					hess[(3*j+k)*n+3*j+l] += b
+					hess[(3*i+k)*n+3*j+l] -= b
+					hess[(3*j+k)*n+3*i+l] -= b
+				}
+			}
+		}
+	}
+	forBends(len(names), func(i, j, k int) {
+		c, g, h := bendCos(coords, i, j, k)
+		atoms := [3]int{i, j, k}
+		for p, a := range atoms {
+			for q, b := range atoms {
+				for x := 0; x < 3; x++ {
+					for y := 0; y < 3; y++ {
+						hess[(3*a+x)*n+3*b+y] += morseBend *
+							(g[p][x]*g[q][y] + (c-morseCos)*h[p][q][x][y])
+					}
+				}
+			}
+		}
+	})
+	return hess
+}
+
+// Analytic implements the Program interface by evaluating
+// analyticPotential in-process, for testing the rest of the program
+// without a quantum chemistry package
+type Analytic struct{}
+
+// MakeHead returns the comment line of an Analytic input file
+func (a Analytic) MakeHead() []string {
+	return []string{"# go-cart analytic potential"}
+}
+
+// MakeFoot returns the empty footer for an Analytic input file
+func (a Analytic) MakeFoot() []string {
+	return []string{}
+}
+
+// MakeIn returns the contents of an Analytic input file, which is
+// just a record of the geometry
+func (a Analytic) MakeIn(names []string, coords []float64) []string {
+	body := make([]string, 0)
+	for i := range names {
+		tmp := make([]string, 0)
+		tmp = append(tmp, names[i])
+		for _, c := range coords[3*i : 3*i+3] {
+			s := strconv.FormatFloat(c, 'f', 10, 64)
+			tmp = append(tmp, s)
+		}
+		body = append(body, strings.Join(tmp, " "))
+	}
+	return MakeInput(a.MakeHead(), a.MakeFoot(), body)
+}
+
+// WriteIn uses MakeIn to write an Analytic input file to filename
+// and then writes the energy to the output file that ReadOut
+// expects, so the job itself has nothing left to do. With
+// useGradients or useHessians, the gradient in Hartree/bohr or the
+// Hessian in Hartree/bohr^2 is written too
+func (a Analytic) WriteIn(filename string, names []string, coords []float64) {
+	lines := a.MakeIn(names, coords)
+	writelines := strings.Join(lines, "\n")
+	err := ioutil.WriteFile(filename, []byte(writelines), 0755)
+	if err != nil {
+		panic(err)
+	}
+	energy := analyticPotential(names, coords)
+	out := "energy= " + strconv.FormatFloat(energy, 'g', -1, 64) + "\n"
+	if useGradients {
+		out += "gradient="
+		for _, g := range analyticGradient(names, coords) {
+			out += " " + strconv.FormatFloat(g*angbohr, 'g', -1, 64)
+		}
+		out += "\n"
+	}
+	if useHessians {
+		out += "hessian="
+		for _, h := range analyticHessian(names, coords) {
+			out += " " + strconv.FormatFloat(h*angbohr*angbohr, 'g', -1, 64)
+		}
+		out += "\n"
+	}
+	err = ioutil.WriteFile(TrimExt(filename)+".out", []byte(out), 0755)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Command returns a command that does nothing, since the energy was
+// already computed by WriteIn
+func (a Analytic) Command(filename string) string {
+	return "true"
+}
+
+// ReadOut reads the energy from an Analytic output file
+func (a Analytic) ReadOut(filename string) (result float64, err error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if _, err = os.Stat(filename); os.IsNotExist(err) {
+		return brokenFloat, ErrFileNotFound
+	}
+	lines, _ := ReadFile(filename)
+	for _, line := range lines {
+		if strings.HasPrefix(line, "energy=") {
+			result, err = strconv.ParseFloat(
+				strings.TrimSpace(line[len("energy="):]), 64)
+			if err != nil {
+				return brokenFloat, ErrEnergyNotParsed
+			}
+			return
+		}
+	}
+	return brokenFloat, ErrBlankOutput
+}
+
+// ReadGradient reads the gradient from an Analytic output file
+func (a Analytic) ReadGradient(filename string) ([]float64, error) {
+	return a.readVector(filename, "gradient=")
+}
+
+// ReadHessian reads the Hessian from an Analytic output file
+func (a Analytic) ReadHessian(filename string) ([]float64, error) {
+	return a.readVector(filename, "hessian=")
+}
+
+// readVector reads the values on the line starting with prefix in an
+// Analytic output file
+func (a Analytic) readVector(filename, prefix string) ([]float64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return nil, ErrFileNotFound
+	}
+	lines, _ := ReadFile(filename)
+	for _, line := range lines {
+		if strings.HasPrefix(line, prefix) {
+			fields := strings.Fields(line[len(prefix):])
+			v := make([]float64, len(fields))
+			for i, f := range fields {
+				g, err := strconv.ParseFloat(f, 64)
+				if err != nil {
+					return nil, ErrEnergyNotParsed
+				}
+				v[i] = g
+			}
+			return v, nil
+		}
+	}
+	return nil, ErrFinishedButNoEnergy
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// monomial is a term in a polynomial potential, Coeff times the
+// product of each coordinate raised to the corresponding power
+type monomial struct {
+	Coeff  float64
+	Powers []int
+}
+
+// derivative returns the derivative of m with respect to the
+// zero-based coordinate indices, evaluated at x
+func (m monomial) derivative(x []float64, indices ...int) float64 {
+	pows := append([]int(nil), m.Powers...)
+	c := m.Coeff
+	for _, i := range indices {
+		if pows[i] == 0 {
+			return 0
+		}
+		c *= float64(pows[i])
+		pows[i]--
+	}
+	for i, p := range pows {
+		c *= math.Pow(x[i], float64(p))
+	}
+	return c
+}
+
+// testPoly is a quartic polynomial in the Cartesian coordinates of a
+// diatomic
+var testPoly = []monomial{
+	{0.5, []int{2, 0, 0, 0, 0, 0}},
+	{0.3, []int{0, 2, 0, 0, 0, 0}},
+	{0.4, []int{0, 0, 2, 0, 0, 0}},
+	{0.6, []int{0, 0, 0, 2, 0, 0}},
+	{0.2, []int{0, 0, 0, 0, 2, 0}},
+	{0.7, []int{0, 0, 0, 0, 0, 2}},
+	{0.04, []int{0, 1, 0, 0, 1, 0}},
+	{0.1, []int{1, 1, 0, 1, 0, 0}},
+	{-0.08, []int{0, 0, 0, 0, 3, 0}},
+	{0.05, []int{0, 0, 2, 0, 0, 2}},
+	{0.03, []int{1, 1, 1, 1, 0, 0}},
+	{0.02, []int{4, 0, 0, 0, 0, 0}},
+}
+
+func polyDerivative(x []float64, indices ...int) (sum float64) {
+	for _, m := range testPoly {
+		sum += m.derivative(x, indices...)
+	}
+	return
+}
+
+// polyGradient is the gradient of testPoly
+func polyGradient(names []string, coords []float64) []float64 {
+	grad := make([]float64, len(coords))
+	for i := range grad {
+		grad[i] = polyDerivative(coords, i)
+	}
+	return grad
+}
+
+// polyHessian is the Hessian of testPoly, flattened by rows
+func polyHessian(names []string, coords []float64) []float64 {
+	n := len(coords)
+	hess := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			hess[i*n+j] = polyDerivative(coords, i, j)
+		}
+	}
+	return hess
+}
+
+// readFort returns the force constants from a SPECTRO fort file,
+// skipping the header
+func readFort(t *testing.T, filename string) []float64 {
+	lines, err := ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcs := make([]float64, 0)
+	for _, line := range lines[1:] {
+		for _, field := range strings.Fields(line) {
+			f, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fcs = append(fcs, f)
+		}
+	}
+	return fcs
+}
+
+func TestMorse(t *testing.T) {
+	t.Run("equilibrium", func(t *testing.T) {
+		got := Morse([]string{"O", "H"}, []float64{0, 0, 0, 0, 0, 0.97})
+		if math.Abs(got) > 1e-12 {
+			t.Errorf("got %v, wanted 0", got)
+		}
+	})
+	t.Run("dissociated", func(t *testing.T) {
+		got := Morse([]string{"O", "H"}, []float64{0, 0, 0, 0, 0, 100})
+		if math.Abs(got-morseDepth) > 1e-12 {
+			t.Errorf("got %v, wanted %v", got, morseDepth)
+		}
+	})
+	t.Run("bends", func(t *testing.T) {
+		// a right angle at O and 45 degrees at each H
+		names := []string{"O", "H", "H"}
+		coords := []float64{0, 0, 0, 0.97, 0, 0, 0, 0.97, 0}
+		var stretch float64
+		for i := range names {
+			for j := i + 1; j < len(names); j++ {
+				stretch += Morse([]string{names[i], names[j]},
+					append(coords[3*i:3*i+3:3*i+3], coords[3*j:3*j+3]...))
+			}
+		}
+		c := math.Sqrt2 / 2
+		want := stretch + morseBend/2*(morseCos*morseCos+
+			2*(c-morseCos)*(c-morseCos))
+		if got := Morse(names, coords); math.Abs(got-want) > 1e-12 {
+			t.Errorf("got %v, wanted %v", got, want)
+		}
+	})
+}
+
+func TestMorseGradient(t *testing.T) {
+	names := []string{"H", "O", "H"}
+	coords := []float64{0.1, 0.8, 0.5, 0, 0, -0.1, 0, -0.7, 0.6}
+	got := MorseGradient(names, coords)
+	const h = 1e-6
+	for i := range coords {
+		want := (Morse(names, nudge(coords, i, h)) -
+			Morse(names, nudge(coords, i, -h))) / (2 * h)
+		if math.Abs(got[i]-want) > 1e-8 {
+			t.Errorf("%d: got %v, wanted %v", i, got[i], want)
+		}
+	}
+}
+
+func TestMorseHessian(t *testing.T) {
+	names := []string{"H", "O", "H"}
+	coords := []float64{0.1, 0.8, 0.5, 0, 0, -0.1, 0, -0.7, 0.6}
+	got := MorseHessian(names, coords)
+	n := len(coords)
+	const h = 1e-6
+	for i := range coords {
+		plus := MorseGradient(names, nudge(coords, i, h))
+		minus := MorseGradient(names, nudge(coords, i, -h))
+		for j := range coords {
+			want := (plus[j] - minus[j]) / (2 * h)
+			if math.Abs(got[i*n+j]-want) > 1e-7 {
+				t.Errorf("%d %d: got %v, wanted %v", i, j, got[i*n+j], want)
+			}
+		}
+	}
+}
+
+// resetRun clears the results, progress, and force constant counts
+// left by an earlier run before a fresh one on ncoords coordinates.
+// It returns the sizes of fc3 and fc4 from InitFCArrays
+func resetRun(ncoords int) (int, int) {
+	energies = make(map[string]float64)
+	gradients = make(map[string][]float64)
+	hessians = make(map[string][]float64)
+	progress = 1
+	return InitFCArrays(ncoords)
+}
+
+// nudge returns a copy of coords with coordinate i moved by h
+func nudge(coords []float64, i int, h float64) []float64 {
+	c := append([]float64(nil), coords...)
+	c[i] += h
+	return c
+}
+
+func TestAnalyticForceField(t *testing.T) {
+	defer func(b int, g, h bool) {
+		batchSize, useGradients, useHessians = b, g, h
+	}(batchSize, useGradients, useHessians)
+	for _, mode := range []string{"energy", "gradient", "hessian"} {
+		for _, size := range []int{1, 7} {
+			t.Run(fmt.Sprintf("%s,batch=%d", mode, size), func(t *testing.T) {
+				useGradients = mode == "gradient"
+				useHessians = mode == "hessian"
+				batchSize = size
+				testAnalyticForceField(t)
+			})
+		}
+	}
+}
+
+// testAnalyticForceField runs a full quartic force field on the
+// polynomial potential and checks the printed force constants
+func testAnalyticForceField(t *testing.T) {
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(t.TempDir())
+	defer func(p Program, q Submission, n, c int, f func([]string, []float64) float64,
+		g, h func([]string, []float64) []float64) {
+		Prog, Queue, nDerivative, checkAfter = p, q, n, c
+		analyticPotential, analyticGradient, analyticHessian = f, g, h
+	}(Prog, Queue, nDerivative, checkAfter,
+		analyticPotential, analyticGradient, analyticHessian)
+	Prog = Analytic{}
+	Queue = Local{}
+	nDerivative = 4
+	checkAfter = 0
+	var calls int64
+	analyticPotential = func(names []string, coords []float64) (energy float64) {
+		atomic.AddInt64(&calls, 1)
+		for _, m := range testPoly {
+			energy += m.derivative(coords)
+		}
+		return
+	}
+	analyticGradient = polyGradient
+	analyticHessian = polyHessian
+	os.Mkdir("inp", 0755)
+	names := []string{"H", "H"}
+	coords := []float64{0.1, -0.2, 0.3, 0.9, 0.05, -0.4}
+	ncoords := len(coords)
+	other3, other4 := resetRun(ncoords)
+	var dump GarbageHeap
+	// the progress is reported on standard error
+	stderr, _ := os.Create("stderr")
+	defer func(f *os.File) { os.Stderr = f }(os.Stderr)
+	os.Stderr = stderr
+	E0 := RefEnergy(names, coords, &dump)
+	RunJobs(names, coords, &dump, E0)
+	stderr.Close()
+	PrintFile15(fc2, len(names), "fort.15")
+	PrintFile30(fc3, len(names), other3, "fort.30")
+	PrintFile40(fc4, len(names), other4, "fort.40")
+	t.Run("unique", func(t *testing.T) {
+		// one for the reference plus one for each displaced geometry
+		want := int64(TotalJobs(nDerivative, ncoords) + 1)
+		if calls != want {
+			t.Errorf("got %d energies, wanted %d", calls, want)
+		}
+	})
+	t.Run("progress", func(t *testing.T) {
+		lines, _ := ReadFile("stderr")
+		var last string
+		for _, line := range lines {
+			if strings.Contains(line, "jobs completed") {
+				last = line
+			}
+		}
+		total := TotalJobs(nDerivative, ncoords)
+		want := fmt.Sprintf("%d/%d jobs completed (100.0%%)", total, total)
+		if last != want {
+			t.Errorf("got %q, wanted %q", last, want)
+		}
+	})
+	const eps = 1e-5
+	t.Run("fort.15", func(t *testing.T) {
+		got := readFort(t, "fort.15")
+		for i := 0; i < ncoords; i++ {
+			for j := 0; j < ncoords; j++ {
+				want := polyDerivative(coords, i, j) * math.Pow(angbohr, 2)
+				if math.Abs(got[ncoords*i+j]-want) > eps {
+					t.Errorf("%d %d: got %v, wanted %v", i+1, j+1,
+						got[ncoords*i+j], want)
+				}
+			}
+		}
+	})
+	t.Run("fort.30", func(t *testing.T) {
+		got := readFort(t, "fort.30")
+		for z := 1; z <= ncoords; z++ {
+			for y := 1; y <= z; y++ {
+				for x := 1; x <= y; x++ {
+					want := polyDerivative(coords, x-1, y-1, z-1) *
+						math.Pow(angbohr, 3)
+					index := Index3(x, y, z)
+					if math.Abs(got[index]-want) > eps {
+						t.Errorf("%d %d %d: got %v, wanted %v",
+							x, y, z, got[index], want)
+					}
+				}
+			}
+		}
+	})
+	t.Run("fort.40", func(t *testing.T) {
+		got := readFort(t, "fort.40")
+		for w := 1; w <= ncoords; w++ {
+			for z := 1; z <= w; z++ {
+				for y := 1; y <= z; y++ {
+					for x := 1; x <= y; x++ {
+						want := polyDerivative(coords, x-1, y-1, z-1, w-1) *
+							math.Pow(angbohr, 4)
+						index := Index4(x, y, z, w)
+						if math.Abs(got[index]-want) > eps {
+							t.Errorf("%d %d %d %d: got %v, wanted %v",
+								x, y, z, w, got[index], want)
+						}
+					}
+				}
+			}
+		}
+	})
+}
+
+func TestAnalyticResume(t *testing.T) {
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(t.TempDir())
+	defer func(p Program, q Submission, n, c int, f func([]string, []float64) float64) {
+		Prog, Queue, nDerivative, checkAfter, analyticPotential = p, q, n, c, f
+	}(Prog, Queue, nDerivative, checkAfter, analyticPotential)
+	Prog = Analytic{}
+	Queue = Local{}
+	nDerivative = 3
+	checkAfter = 0
+	analyticPotential = Morse
+	os.Mkdir("inp", 0755)
+	names := []string{"O", "H"}
+	coords := []float64{0, 0, 0, 0, 0.1, 1.0}
+	resetRun(len(coords))
+	var dump GarbageHeap
+	E0 := RefEnergy(names, coords, &dump)
+	RunJobs(names, coords, &dump, E0)
+	MakeCheckpoint()
+	want := fc3
+	energies = make(map[string]float64)
+	ReadCheckpoint()
+	// lose the force constants and progress but keep the energies
+	progress = 1
+	InitFCArrays(len(coords))
+	analyticPotential = func([]string, []float64) float64 {
+		t.Error("energy recomputed after resume")
+		return 0
+	}
+	RunJobs(names, coords, &dump, E0)
+	for i := range want {
+		if math.Abs(fc3[i]-want[i]) > 1e-12 {
+			t.Errorf("%d: got %v, wanted %v", i, fc3[i], want[i])
+		}
+	}
+}
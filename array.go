@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+// arrayJobs holds the Jobs waiting to be submitted as a job array,
+// keyed by derivative level. Like batch, it is only touched by Drain
+// and SubmitArrays
+var arrayJobs = make(map[int][]Job)
+
+// ArrayLine returns the line of an index file that runs infile. When
+// watching, each task also touches its own sentinel, so that every
+// task that finishes wakes the waiter for the array
+func ArrayLine(infile string) string {
+	line := JobCommand(infile)
+	if completion == "WATCH" {
+		line += "; touch " + DoneFile(infile)
+	}
+	return line
+}
+
+// WriteArray writes the index file for infiles to indexfile and uses
+// a.MakeArray to write the array script to pbsfile
+func WriteArray(a ArraySubmission, pbsfile, indexfile string, infiles []string,
+	Sig1 int, dump *GarbageHeap) {
+	index := make([]string, 0, len(infiles))
+	for _, infile := range infiles {
+		index = append(index, ArrayLine(infile))
+	}
+	err := ioutil.WriteFile(indexfile, []byte(strings.Join(index, "\n")+"\n"), 0755)
+	if err != nil {
+		panic(err)
+	}
+	lines := a.MakeArray(indexfile, len(infiles), Sig1, dump)
+	err = ioutil.WriteFile(pbsfile, []byte(strings.Join(lines, "\n")), 0755)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SubmitArrays submits the Jobs in arrayJobs as one job array per
+// derivative level
+func SubmitArrays(names []string, coords []float64, wg *sync.WaitGroup,
+	totalJobs int, dump *GarbageHeap, E0 float64) {
+	for level := 2; level <= 4; level++ {
+		if jobs, ok := arrayJobs[level]; ok {
+			wg.Add(1)
+			go QueueAndWaitArray(jobs, names, coords, wg, totalJobs, dump, E0)
+			delete(arrayJobs, level)
+		}
+	}
+}
+
+// QueueAndWaitArray submits jobs to the Queue as a single job array
+// and waits on their results. Task i of the array runs the ith job
+// that still needs to be run
+func QueueAndWaitArray(jobs []Job, names []string, coords []float64, wg *sync.WaitGroup,
+	totalJobs int, dump *GarbageHeap, E0 float64) {
+
+	defer wg.Done()
+	waiting := WriteMembers(jobs, names, coords, totalJobs, E0)
+	if len(waiting) == 0 {
+		return
+	}
+	infiles := make([]string, 0, len(waiting))
+	for _, m := range waiting {
+		infiles = append(infiles, m.infile)
+	}
+	name := HashName()
+	ajob := Job{Name: name, Sig1: waiting[0].job.Sig1}
+	pbsfile := "inp/" + name + ".pbs"
+	WriteArray(Queue.(ArraySubmission), pbsfile, "inp/"+name+".idx", infiles,
+		ajob.Sig1, dump)
+	ajob.Number = Submit(pbsfile)
+	// every task signals or touches its own sentinel, so only the
+	// Queue knows when the whole array is over
+	WaitMembers(ajob, waiting, false, true, len(coords), totalJobs, dump)
+	Forget(ajob.Number)
+	dump.Add(name)
+}
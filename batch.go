@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+// batch holds the Jobs waiting to fill a batch of batchSize. It is
+// only touched by Drain and FlushBatch, which run in order
+var batch []Job
+
+// MakeBatch returns the contents of a queue script running Prog on
+// each of infiles one after another and signaling Sig1 when they are
+// all finished. done is the sentinel file for the whole batch
+func MakeBatch(infiles []string, Sig1 int, done string, dump *GarbageHeap) []string {
+	body := make([]string, 0, len(infiles))
+	for _, infile := range infiles {
+		body = append(body, JobCommand(infile))
+	}
+	if jobTemplate != nil {
+		return ExecJob(JobData{
+			Input:    strings.Join(infiles, " "),
+			Command:  strings.Join(body, "\n"),
+			Signal:   Sig1,
+			ProgName: progName,
+			Done:     done,
+			Cleanup:  strings.Join(dump.Dump(), "\n"),
+		})
+	}
+	if completion == "WATCH" {
+		body = append(body, "touch "+done)
+	}
+	return MakeInput(Queue.MakeHead(), Queue.MakeFoot(Sig1, dump), body)
+}
+
+// WriteBatch uses MakeBatch to write a batch queue script to pbsfile
+func WriteBatch(pbsfile string, infiles []string, Sig1 int, done string, dump *GarbageHeap) {
+	lines := MakeBatch(infiles, Sig1, done, dump)
+	writelines := strings.Join(lines, "\n")
+	err := ioutil.WriteFile(pbsfile, []byte(writelines), 0755)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// FlushBatch submits the pending batch, if there is one, as a single
+// worker
+func FlushBatch(names []string, coords []float64, wg *sync.WaitGroup,
+	ch chan int, totalJobs int, dump *GarbageHeap, E0 float64) {
+
+	if len(batch) == 0 {
+		return
+	}
+	wg.Add(1)
+	workersMutex.Lock()
+	workers++
+	workersMutex.Unlock()
+	ch <- 1
+	jobs := batch
+	batch = nil
+	go QueueAndWaitBatch(jobs, names, coords, wg, ch, totalJobs, dump, E0)
+}
+
+// Definitive reports whether a nil error from Await means that the
+// job really finished, rather than that some other job sharing its
+// signal did
+func Definitive() bool {
+	if completion != "SIGNAL" {
+		return true
+	}
+	_, ok := Queue.(Waiter)
+	return ok
+}
+
+// batchMember holds a Job in a batch along with its files
+type batchMember struct {
+	job     Job
+	infile  string
+	pbsfile string
+	outfile string
+}
+
+// WriteMembers writes the input files for jobs that are not already
+// known and returns them as batchMembers, recording the rest
+// directly
+func WriteMembers(jobs []Job, names []string, coords []float64,
+	totalJobs int, E0 float64) (waiting []batchMember) {
+	for _, job := range jobs {
+		if CachedResult(&job, len(coords), E0) {
+			RecordResult(job, len(coords), totalJobs)
+			continue
+		}
+		infile, pbsfile, outfile := WriteJob(job, names, coords)
+		waiting = append(waiting, batchMember{job, infile, pbsfile, outfile})
+	}
+	return
+}
+
+// JobOver reports whether the Queue says that job num has finished
+// or is no longer known to it. If the Queue cannot say, it is not
+// over yet, and the caller asks again after its next wait
+func JobOver(num int) bool {
+	states, err := Queue.Status([]int{num})
+	if err != nil {
+		fmt.Println("error checking job", num, "for", err)
+		return false
+	}
+	state, ok := states[num]
+	return ok && state.Finished()
+}
+
+// WaitMembers waits on the job bjob running all of the members in
+// waiting and records their results, requeueing members that fail
+// individually. Once bjob is over, any members still missing have
+// failed too. If definitive, a nil error from Await means that it is
+// over, and otherwise the Queue is asked after every wait, before
+// reading the outputs so that none written in between are missed. If
+// tasks, each member touches its own sentinel when watching, so the
+// wait is for any of theirs instead of one for bjob
+func WaitMembers(bjob Job, waiting []batchMember, definitive, tasks bool,
+	ncoords, totalJobs int, dump *GarbageHeap) {
+	var requeued []batchMember
+	for len(waiting) > 0 {
+		var werr error
+		if tasks && completion == "WATCH" {
+			names := make([]string, 0, len(waiting))
+			for _, m := range waiting {
+				names = append(names, m.job.Name)
+			}
+			werr = WatchWaitAny(names, watchTimeout)
+		} else {
+			werr = Await(bjob, timeBeforeRetry)
+		}
+		over := werr == nil && definitive
+		if !definitive {
+			over = JobOver(bjob.Number)
+		}
+		var still []batchMember
+		for _, m := range waiting {
+			energy, err := ReadResult(m.job, m.outfile)
+			switch {
+			case err == nil:
+				m.job.Status = "done"
+				m.job.Result = energy
+				RecordResult(m.job, ncoords, totalJobs)
+				ClearDone(m.job.Name)
+				dump.Add(m.job.Name)
+			case Failed(err) || over:
+				fmt.Println("requeueing", m.outfile, "for", err)
+				Queue.Write(m.pbsfile, m.infile, m.job.Sig1, dump)
+				m.job.Number = Submit(m.pbsfile)
+				requeued = append(requeued, m)
+			default:
+				still = append(still, m)
+			}
+		}
+		waiting = still
+	}
+	for _, m := range requeued {
+		m.job.Result = WaitResult(&m.job, m.pbsfile, m.outfile)
+		m.job.Status = "done"
+		RecordResult(m.job, ncoords, totalJobs)
+		dump.Add(m.job.Name)
+	}
+}
+
+// QueueAndWaitBatch submits jobs to the Queue as a single batch and
+// waits on their results
+func QueueAndWaitBatch(jobs []Job, names []string, coords []float64, wg *sync.WaitGroup,
+	ch chan int, totalJobs int, dump *GarbageHeap, E0 float64) {
+
+	defer wg.Done()
+	waiting := WriteMembers(jobs, names, coords, totalJobs, E0)
+	if len(waiting) > 0 {
+		infiles := make([]string, 0, len(waiting))
+		for _, m := range waiting {
+			infiles = append(infiles, m.infile)
+		}
+		name := HashName()
+		bjob := Job{Name: name, Sig1: waiting[0].job.Sig1}
+		pbsfile := "inp/" + name + ".pbs"
+		WriteBatch(pbsfile, infiles, bjob.Sig1, "inp/"+name+".done", dump)
+		bjob.Number = Submit(pbsfile)
+		WaitMembers(bjob, waiting, Definitive(), false, len(coords), totalJobs, dump)
+		Forget(bjob.Number)
+		ClearDone(name)
+		dump.Add(name)
+	}
+	workersMutex.Lock()
+	workers--
+	workersMutex.Unlock()
+	<-ch
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMakeBatch(t *testing.T) {
+	defer func(q Submission, c string) { Queue, completion = q, c }(Queue, completion)
+	Queue = Local{}
+	infiles := []string{"inp/job1.inp", "inp/job2.inp"}
+	tests := []struct {
+		completion string
+		want       []string
+	}{
+		{"SIGNAL", []string{
+			"#!/bin/sh",
+			"molpro -t 1 inp/job1.inp",
+			"molpro -t 1 inp/job2.inp",
+			"rm test1*"}},
+		{"WATCH", []string{
+			"#!/bin/sh",
+			"molpro -t 1 inp/job1.inp",
+			"molpro -t 1 inp/job2.inp",
+			"touch inp/batch.done",
+			"rm test1*"}},
+	}
+	for _, test := range tests {
+		t.Run(test.completion, func(t *testing.T) {
+			completion = test.completion
+			tdump := GarbageHeap{Heap: []string{"test1"}}
+			got := MakeBatch(infiles, 35, "inp/batch.done", &tdump)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, wanted %#v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+)
+
+// Outstanding jobs, which are cancelled on interrupt
+var (
+	outstanding      = make(map[int]bool)
+	outstandingMutex sync.Mutex
+)
+
+// Submit submits pbsfile to the Queue and records the job as
+// outstanding until Forget is called on its number. Any completion
+// latched for an earlier run of the same job is cleared first
+func Submit(pbsfile string) int {
+	ClearDone(Basename(pbsfile))
+	num := Queue.Submit(pbsfile)
+	outstandingMutex.Lock()
+	outstanding[num] = true
+	outstandingMutex.Unlock()
+	return num
+}
+
+// Forget removes the job numbered num from the outstanding jobs
+func Forget(num int) {
+	outstandingMutex.Lock()
+	delete(outstanding, num)
+	outstandingMutex.Unlock()
+}
+
+// Outstanding returns the sorted numbers of the jobs that have been
+// submitted but not forgotten
+func Outstanding() []int {
+	outstandingMutex.Lock()
+	defer outstandingMutex.Unlock()
+	ids := make([]int, 0, len(outstanding))
+	for id := range outstanding {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// HandleInterrupt starts a goroutine that, on SIGINT or SIGTERM,
+// cancels every outstanding job, writes a checkpoint, and exits
+func HandleInterrupt() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigChan
+		ids := Outstanding()
+		fmt.Fprintf(os.Stderr, "caught %s, cancelling %d outstanding jobs\n",
+			sig, len(ids))
+		if len(ids) > 0 {
+			if err := Queue.Cancel(ids); err != nil {
+				fmt.Fprintln(os.Stderr, "error cancelling jobs:", err)
+			}
+		}
+		MakeCheckpoint()
+		energiesMutex.RLock()
+		done := progress - 1
+		energiesMutex.RUnlock()
+		fmt.Fprintf(os.Stderr, "%d jobs completed, checkpoint written, "+
+			"resume with -c -o\n", done)
+		os.Exit(1)
+	}()
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOutstanding(t *testing.T) {
+	defer func(q Submission) { Queue = q }(Queue)
+	Queue = Local{}
+	a := Submit("/dev/null")
+	b := Submit("/dev/null")
+	want := []int{a, b}
+	if got := Outstanding(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+	Forget(a)
+	want = []int{b}
+	if got := Outstanding(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+	Forget(b)
+	if got := Outstanding(); len(got) != 0 {
+		t.Errorf("got %v, wanted none", got)
+	}
+}
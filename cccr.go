@@ -114,6 +114,9 @@ func (c CcCR) MakeIn(names []string, coords []float64) []string {
 		}
 		body = append(body, strings.Join(tmp, " "))
 	}
+	if inputTemplate != nil {
+		return TemplateIn(body, molproMethod)
+	}
 	return MakeInput(c.MakeHead(), c.MakeFoot(), body)
 }
 
@@ -127,6 +130,12 @@ func (c CcCR) WriteIn(filename string, names []string, coords []float64) {
 	}
 }
 
+// Command returns the command for running Molpro on the CcCR input
+// in filename
+func (c CcCR) Command(filename string) string {
+	return "molpro -t 1 " + filename
+}
+
 // ReadOut reads a CcCR Molpro output file and returns the resulting
 // energy
 func (c CcCR) ReadOut(filename string) (result float64, err error) {
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	cfourEnergy   = "The final electronic energy is"
+	cfourFinished = "This computation required"
+	cfourStatus   = "finished with status"
+)
+
+// CFour implements the Program and ScratchProgram interfaces. CFOUR
+// always reads its input from ZMAT in the current directory, so each
+// job is run in its own directory
+type CFour struct{}
+
+// InName returns the fixed name of a CFOUR input file
+func (c CFour) InName() string {
+	return "ZMAT"
+}
+
+// OutName returns the name CFOUR output is written to
+func (c CFour) OutName() string {
+	return "output.dat"
+}
+
+// MakeHead returns the title line of a CFOUR ZMAT file
+func (c CFour) MakeHead() []string {
+	return []string{"go-cart"}
+}
+
+// MakeFoot returns the keyword section of a CFOUR ZMAT file
+func (c CFour) MakeFoot() []string {
+	ref := "RHF"
+	if spin != "0" {
+		ref = "UHF"
+	}
+	return []string{"",
+		"*CFOUR(CALC=" + cfourMethod + ",BASIS=" + basis + ",REF=" + ref,
+		"CHARGE=" + charge + ",MULT=" + Multiplicity(),
+		"COORD=CARTESIAN,UNITS=ANGSTROM,SYMMETRY=OFF",
+		"SCF_CONV=10,CC_CONV=10)",
+		"", ""}
+}
+
+// MakeIn returns the contents of a CFOUR ZMAT file
+func (c CFour) MakeIn(names []string, coords []float64) []string {
+	body := make([]string, 0)
+	for i := range names {
+		tmp := make([]string, 0)
+		tmp = append(tmp, names[i])
+		for _, c := range coords[3*i : 3*i+3] {
+			s := strconv.FormatFloat(c, 'f', 10, 64)
+			tmp = append(tmp, s)
+		}
+		body = append(body, strings.Join(tmp, " "))
+	}
+	if inputTemplate != nil {
+		return TemplateIn(body, cfourMethod)
+	}
+	return MakeInput(c.MakeHead(), c.MakeFoot(), body)
+}
+
+// WriteIn uses MakeIn to write a CFOUR ZMAT file to filename,
+// creating its scratch directory first
+func (c CFour) WriteIn(filename string, names []string, coords []float64) {
+	err := os.MkdirAll(path.Dir(filename), 0755)
+	if err != nil {
+		panic(err)
+	}
+	lines := c.MakeIn(names, coords)
+	writelines := strings.Join(lines, "\n")
+	err = ioutil.WriteFile(filename, []byte(writelines), 0755)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Command returns the command for running CFOUR in the directory
+// containing filename. The GENBAS file is copied from the directory
+// go-cart is run in
+func (c CFour) Command(filename string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+	return "(cd " + path.Dir(filename) + " && cp " + wd + "/GENBAS . && " +
+		"xcfour > " + c.OutName() + ")"
+}
+
+// ReadOut reads a CFOUR output file and returns the final
+// electronic energy
+func (c CFour) ReadOut(filename string) (result float64, err error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if _, err = os.Stat(filename); os.IsNotExist(err) {
+		return brokenFloat, ErrFileNotFound
+	}
+	err = ErrEnergyNotFound
+	result = brokenFloat
+	lines, _ := ReadFile(filename)
+	if len(lines) == 1 {
+		return result, ErrBlankOutput
+	}
+	for _, line := range lines {
+		if strings.Contains(line, "ERROR") {
+			return brokenFloat, ErrFileContainsError
+		}
+		// every executable reports its exit status
+		if strings.Contains(line, cfourStatus) {
+			fields := strings.Fields(line[strings.Index(line, cfourStatus)+len(cfourStatus):])
+			if len(fields) > 0 && fields[0] != "0" {
+				return brokenFloat, ErrFileContainsError
+			}
+		}
+		if strings.HasPrefix(line, cfourEnergy) {
+			fields := strings.Fields(line[len(cfourEnergy):])
+			if len(fields) == 0 {
+				return brokenFloat, ErrEnergyNotParsed
+			}
+			result, err = strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return brokenFloat, ErrEnergyNotParsed
+			}
+		}
+		if strings.Contains(line, cfourFinished) && err != nil {
+			err = ErrFinishedButNoEnergy
+		}
+	}
+	return
+}
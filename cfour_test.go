@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMakeCFourIn(t *testing.T) {
+	want := []string{
+		"go-cart",
+		"H 0.0000000000 0.7574590974 0.5217905143",
+		"O 0.0000000000 0.0000000000 -0.0657441568",
+		"H 0.0000000000 -0.7574590974 0.5217905143",
+		"",
+		"*CFOUR(CALC=CCSD(T),BASIS=cc-pVTZ-F12,REF=RHF",
+		"CHARGE=0,MULT=1",
+		"COORD=CARTESIAN,UNITS=ANGSTROM,SYMMETRY=OFF",
+		"SCF_CONV=10,CC_CONV=10)",
+		"", ""}
+	got := CFour{}.MakeIn(testnames, testcoords)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwanted %#v\n", got, want)
+	}
+}
+
+func TestReadCFourOut(t *testing.T) {
+	tests := []struct {
+		msg      string
+		filename string
+		want     float64
+		err      error
+	}{
+		{"final electronic energy", "testfiles/cfour/output.dat", -76.333586167595, nil},
+		{"no output file", "testfiles/cfour1/output.dat", brokenFloat, ErrFileNotFound},
+		{"nonzero exit status", "testfiles/cfourerr/output.dat", brokenFloat, ErrFileContainsError},
+		{"finished but no energy", "testfiles/cfournoenergy/output.dat", brokenFloat,
+			ErrFinishedButNoEnergy},
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			got, err := CFour{}.ReadOut(test.filename)
+			if err != test.err {
+				t.Errorf("got error %v, wanted %v", err, test.err)
+			}
+			if math.IsNaN(test.want) {
+				if !math.IsNaN(got) {
+					t.Errorf("got %v, wanted %v", got, test.want)
+				}
+			} else if got != test.want {
+				t.Errorf("got %v, wanted %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCFourJobFiles(t *testing.T) {
+	temp := Prog
+	defer func() { Prog = temp }()
+	Prog = CFour{}
+	infile, pbsfile, outfile := JobFiles("job")
+	got := []string{infile, pbsfile, outfile}
+	want := []string{"inp/job/ZMAT", "inp/job.pbs", "inp/job/output.dat"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+	var tdump GarbageHeap
+	tdump.Add("job")
+	gotDump := tdump.Dump()
+	wantDump := []string{"rm inp/job*", "rm -rf inp/job"}
+	if !reflect.DeepEqual(gotDump, wantDump) {
+		t.Errorf("got %v, wanted %v", gotDump, wantDump)
+	}
+}
@@ -1,5 +1,16 @@
 package main
 
+import "sort"
+
+// Make1D makes the Job slices for finite differences first
+// derivatives, which are only used on gradients
+func Make1D(i int) []Job {
+	// g(+i) - g(-i) / 2d
+	return []Job{
+		Job{1, HashName(), 0, 0, []int{i}, []int{i}, "queued", 0, 0},
+		Job{-1, HashName(), 0, 0, []int{-i}, []int{i}, "queued", 0, 0}}
+}
+
 // Make2D makes the Job slices for finite differences second
 // derivative force constants
 func Make2D(i, j int) []Job {
@@ -274,8 +285,15 @@ func Make4D(i, j, k, l int) []Job {
 	}
 }
 
-// Derivative is a helper for calling Make(2|3|4)D in the same way
+// Derivative is a helper for calling Make(2|3|4)D in the same way,
+// or GradDerivative or HessDerivative when using gradients or Hessians
 func Derivative(dims ...int) []Job {
+	switch {
+	case useHessians:
+		return HessDerivative(dims...)
+	case useGradients:
+		return GradDerivative(dims...)
+	}
 	switch len(dims) {
 	case 2:
 		return Make2D(dims[0], dims[1])
@@ -286,3 +304,70 @@ func Derivative(dims ...int) []Job {
 	}
 	return []Job{Job{}}
 }
+
+// GradDerivative makes the Job slices for the force constant with
+// indices dims from finite differences of gradients. The smallest
+// index is the gradient component, which is differentiated along the
+// rest with the stencil for one derivative lower, and it is always
+// Index[0] so that it can be recovered even after sorting. The Coeffs
+// make up the difference between the denominator of that stencil and
+// the one PrintFile(15|30|40) expects, and convert the gradient from
+// Hartree/bohr to Hartree/Angstrom
+func GradDerivative(dims ...int) []Job {
+	index := append([]int(nil), dims...)
+	sort.Ints(index)
+	var jobs []Job
+	switch rest := index[1:]; len(rest) {
+	case 1:
+		jobs = Make1D(rest[0])
+	case 2:
+		jobs = Make2D(rest[0], rest[1])
+	case 3:
+		jobs = Make3D(rest[0], rest[1], rest[2])
+	default:
+		return []Job{Job{}}
+	}
+	for i := range jobs {
+		jobs[i].Coeff *= 2 * delta / angbohr
+		jobs[i].Index = append([]int(nil), index...)
+	}
+	return jobs
+}
+
+// HessDerivative makes the Job slices for the force constant with
+// indices dims from finite differences of Hessians. Two of the
+// indices pick the Hessian component, which leads Index, and it is
+// differentiated along the rest. For the quartic force constants, the
+// rest is a repeated index when there is one, so that only steps
+// along a single coordinate are needed. Ones with four distinct
+// indices still need a step along each of two coordinates, since a
+// Hessian element only covers two of them, so a quartic field takes
+// O(N^2) geometries rather than O(N). The Coeffs play the same role
+// as in GradDerivative, converting the Hessian from Hartree/bohr^2
+func HessDerivative(dims ...int) []Job {
+	index := append([]int(nil), dims...)
+	sort.Ints(index)
+	var jobs []Job
+	switch len(index) {
+	case 2:
+		jobs = []Job{Job{1, "E0", 0, 0, []int{}, nil, "queued", 0, 0}}
+	case 3:
+		jobs = Make1D(index[2])
+	case 4:
+		for i := 0; i < 3; i++ {
+			if index[i] == index[i+1] {
+				r := index[i]
+				index = append(append(index[:i:i], index[i+2:]...), r, r)
+				break
+			}
+		}
+		jobs = Make2D(index[2], index[3])
+	default:
+		return []Job{Job{}}
+	}
+	for i := range jobs {
+		jobs[i].Coeff *= 4 * delta * delta / (angbohr * angbohr)
+		jobs[i].Index = append([]int(nil), index...)
+	}
+	return jobs
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	gaussianNormal    = "Normal termination"
+	gaussianError     = "Error termination"
+	gaussianFchkTotal = "Total Energy"
+	gaussianFchkFCs   = "Cartesian Force Constants"
+)
+
+// gaussianEnergies are the keys of the energies in a Gaussian archive
+// block. They are printed in order of increasing level of theory, so
+// the last one found is the energy for the requested method
+var gaussianEnergies = []string{
+	"HF", "MP2", "MP3", "MP4D", "MP4DQ", "MP4SDQ", "MP4SDTQ", "MP5",
+	"CISD", "QCISD", "QCISD(T)", "CCSD", "CCSD(T)", "BD", "BD(T)",
+}
+
+// Gaussian implements the Program interface
+type Gaussian struct{}
+
+// MakeHead returns the header for a Gaussian input file, through the
+// charge and multiplicity line. With useHessians, it also asks for
+// the Hessian in the input orientation
+func (g Gaussian) MakeHead() []string {
+	route := "#P " + gaussianMethod + "/" + basis + " SCF=Tight"
+	if useHessians {
+		route += " Freq NoSymm"
+	}
+	return []string{"%mem=1GB",
+		"%nproc=1",
+		route,
+		"",
+		"go-cart",
+		"",
+		charge + " " + Multiplicity()}
+}
+
+// MakeFoot returns the footer for a Gaussian input file, which is
+// just the blank line Gaussian requires after the geometry
+func (g Gaussian) MakeFoot() []string {
+	return []string{"", ""}
+}
+
+// MakeIn returns the contents of a Gaussian input file
+func (g Gaussian) MakeIn(names []string, coords []float64) []string {
+	body := make([]string, 0)
+	for i := range names {
+		tmp := make([]string, 0)
+		tmp = append(tmp, names[i])
+		for _, c := range coords[3*i : 3*i+3] {
+			s := strconv.FormatFloat(c, 'f', 10, 64)
+			tmp = append(tmp, s)
+		}
+		body = append(body, strings.Join(tmp, " "))
+	}
+	if inputTemplate != nil {
+		return TemplateIn(body, gaussianMethod)
+	}
+	return MakeInput(g.MakeHead(), g.MakeFoot(), body)
+}
+
+// WriteIn uses MakeIn to write a Gaussian input file to filename,
+// adding a checkpoint file with the same name for formchk
+func (g Gaussian) WriteIn(filename string, names []string, coords []float64) {
+	lines := append([]string{"%chk=" + TrimExt(filename) + ".chk"},
+		g.MakeIn(names, coords)...)
+	writelines := strings.Join(lines, "\n")
+	err := ioutil.WriteFile(filename, []byte(writelines), 0755)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Command returns the command for running Gaussian on filename and
+// formatting the resulting checkpoint file
+func (g Gaussian) Command(filename string) string {
+	base := TrimExt(filename)
+	return "g16 < " + filename + " > " + base + ".out && " +
+		"formchk " + base + ".chk " + base + ".fchk"
+}
+
+// ReadOut reads a Gaussian output file and returns the energy from
+// its archive block, or from the formatted checkpoint file alongside
+// it if there is no archive
+func (g Gaussian) ReadOut(filename string) (result float64, err error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if _, err = os.Stat(filename); os.IsNotExist(err) {
+		return brokenFloat, ErrFileNotFound
+	}
+	err = ErrEnergyNotFound
+	result = brokenFloat
+	lines, _ := ReadFile(filename)
+	if len(lines) == 1 {
+		return result, ErrBlankOutput
+	}
+	var (
+		archive  strings.Builder
+		inBlock  bool
+		started  bool
+		complete string
+		finished bool
+	)
+	for _, line := range lines {
+		if strings.Contains(line, gaussianError) {
+			return brokenFloat, ErrFileContainsError
+		}
+		if strings.Contains(line, gaussianNormal) {
+			finished = true
+		}
+		// archive lines are wrapped without regard to fields, so
+		// join them back together
+		if strings.HasPrefix(line, `1\1\`) {
+			inBlock = true
+			started = true
+			archive.Reset()
+		}
+		if inBlock {
+			archive.WriteString(line)
+			if strings.HasSuffix(line, `\@`) {
+				inBlock = false
+				complete = archive.String()
+			}
+		}
+	}
+	// a block still being written can end in the middle of a
+	// number or before the highest level of theory
+	switch {
+	case complete != "":
+		result, err = gaussianArchiveEnergy(complete)
+	case started:
+	default:
+		result, err = gaussianFchkEnergy(TrimExt(filename) + ".fchk")
+	}
+	if err == ErrEnergyNotFound && finished {
+		err = ErrFinishedButNoEnergy
+	}
+	return
+}
+
+// gaussianArchiveEnergy returns the energy for the highest level of
+// theory in the Gaussian archive block archive
+func gaussianArchiveEnergy(archive string) (float64, error) {
+	result, err := brokenFloat, ErrEnergyNotFound
+	for _, field := range strings.Split(archive, `\`) {
+		split := strings.SplitN(field, "=", 2)
+		if len(split) != 2 {
+			continue
+		}
+		for _, key := range gaussianEnergies {
+			if split[0] == key {
+				result, err = strconv.ParseFloat(split[1], 64)
+				if err != nil {
+					return brokenFloat, ErrEnergyNotParsed
+				}
+			}
+		}
+	}
+	return result, err
+}
+
+// gaussianFchkEnergy returns the total energy from the formatted
+// checkpoint file filename
+func gaussianFchkEnergy(filename string) (float64, error) {
+	lines, err := ReadFile(filename)
+	if err != nil {
+		return brokenFloat, ErrEnergyNotFound
+	}
+	for _, line := range lines {
+		if strings.HasPrefix(line, gaussianFchkTotal) {
+			fields := strings.Fields(line)
+			f, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+			if err != nil {
+				return brokenFloat, ErrEnergyNotParsed
+			}
+			return f, nil
+		}
+	}
+	return brokenFloat, ErrEnergyNotFound
+}
+
+// ReadHessian reads the Cartesian force constants from the formatted
+// checkpoint file alongside the Gaussian output file filename
+func (g Gaussian) ReadHessian(filename string) ([]float64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return nil, ErrFileNotFound
+	}
+	lines, _ := ReadFile(filename)
+	if len(lines) == 1 {
+		return nil, ErrBlankOutput
+	}
+	for _, line := range lines {
+		if strings.Contains(line, gaussianError) {
+			return nil, ErrFileContainsError
+		}
+	}
+	// formchk only runs once Gaussian is done
+	fchk, err := ReadFile(TrimExt(filename) + ".fchk")
+	if err != nil {
+		return nil, ErrHessianNotFound
+	}
+	return gaussianFchkHessian(fchk)
+}
+
+// gaussianFchkHessian returns the Hessian from the lines of a
+// formatted checkpoint file, which holds its lower triangle by rows
+func gaussianFchkHessian(lines []string) ([]float64, error) {
+	for i, line := range lines {
+		if !strings.HasPrefix(line, gaussianFchkFCs) {
+			continue
+		}
+		fields := strings.Fields(line)
+		size, err := strconv.Atoi(fields[len(fields)-1])
+		if err != nil {
+			return nil, ErrEnergyNotParsed
+		}
+		tri := make([]float64, 0, size)
+		for _, line := range lines[i+1:] {
+			if len(tri) == size {
+				break
+			}
+			for _, f := range strings.Fields(line) {
+				v, err := strconv.ParseFloat(f, 64)
+				if err != nil {
+					return nil, ErrEnergyNotParsed
+				}
+				tri = append(tri, v)
+			}
+		}
+		if len(tri) != size {
+			// formchk is still writing
+			return nil, ErrHessianNotFound
+		}
+		n := 0
+		for n*(n+1)/2 < size {
+			n++
+		}
+		hess := make([]float64, n*n)
+		k := 0
+		for r := 0; r < n; r++ {
+			for c := 0; c <= r; c++ {
+				hess[r*n+c] = tri[k]
+				hess[c*n+r] = tri[k]
+				k++
+			}
+		}
+		return hess, nil
+	}
+	return nil, ErrFinishedButNoEnergy
+}
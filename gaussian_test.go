@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMakeGaussianIn(t *testing.T) {
+	want := []string{
+		"%mem=1GB",
+		"%nproc=1",
+		"#P CCSD(T)/cc-pVTZ-F12 SCF=Tight",
+		"",
+		"go-cart",
+		"",
+		"0 1",
+		"H 0.0000000000 0.7574590974 0.5217905143",
+		"O 0.0000000000 0.0000000000 -0.0657441568",
+		"H 0.0000000000 -0.7574590974 0.5217905143",
+		"", ""}
+	got := Gaussian{}.MakeIn(testnames, testcoords)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwanted %#v\n", got, want)
+	}
+}
+
+func TestReadGaussianOut(t *testing.T) {
+	tests := []struct {
+		msg      string
+		filename string
+		want     float64
+		err      error
+	}{
+		{"archive block", "testfiles/gaussian.out", -76.3335862, nil},
+		{"formatted checkpoint", "testfiles/gaussianfchk.out", -76.333586167595, nil},
+		{"partial archive block", "testfiles/gaussiantrunc.out", brokenFloat,
+			ErrEnergyNotFound},
+		{"no output file", "testfiles/gaussian1.out", brokenFloat, ErrFileNotFound},
+		{"error termination", "testfiles/gaussianerr.out", brokenFloat, ErrFileContainsError},
+		{"finished but no energy", "testfiles/gaussiannoenergy.out", brokenFloat,
+			ErrFinishedButNoEnergy},
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			got, err := Gaussian{}.ReadOut(test.filename)
+			if err != test.err {
+				t.Errorf("got error %v, wanted %v", err, test.err)
+			}
+			if math.IsNaN(test.want) {
+				if !math.IsNaN(got) {
+					t.Errorf("got %v, wanted %v", got, test.want)
+				}
+			} else if got != test.want {
+				t.Errorf("got %v, wanted %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadGaussianHessian(t *testing.T) {
+	tests := []struct {
+		msg      string
+		filename string
+		want     []float64
+		err      error
+	}{
+		{"formatted checkpoint", "testfiles/gaussianfreq.out", waterHessian(), nil},
+		{"no output file", "testfiles/gaussian1.out", nil, ErrFileNotFound},
+		{"error termination", "testfiles/gaussianerr.out", nil, ErrFileContainsError},
+		{"no checkpoint", "testfiles/gaussian.out", nil, ErrHessianNotFound},
+		{"finished but no hessian", "testfiles/gaussianfchk.out", nil,
+			ErrFinishedButNoEnergy},
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			got, err := Gaussian{}.ReadHessian(test.filename)
+			if err != test.err {
+				t.Errorf("got error %v, wanted %v", err, test.err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, wanted %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// GradientProgram is implemented by Programs that can compute the
+// Cartesian gradient in Hartree/bohr in the same job as the energy
+type GradientProgram interface {
+	ReadGradient(filename string) ([]float64, error)
+}
+
+// gradientFile holds the finished gradients at each checkpoint,
+// alongside snapshotFile
+const gradientFile = "gradients.json"
+
+// Finished gradients and Hessians, keyed by EnergyKey like energies
+// and guarded by energiesMutex. Each one is for the geometry with its
+// key, even if it was computed at an equivalent one, and each Hessian
+// is flattened by rows. Ones read by ReadResult wait in unrecorded
+// until RecordResult takes them
+var (
+	useGradients bool = false
+	useHessians  bool = false
+	gradients         = make(map[string][]float64)
+	hessians          = make(map[string][]float64)
+	unrecorded        = make(map[string][]float64)
+)
+
+// UseDerivatives reports whether the force constants are built from
+// gradients or Hessians instead of energies
+func UseDerivatives() bool {
+	return useGradients || useHessians
+}
+
+// Derivatives returns gradients or hessians, whichever is in use, or
+// nil if neither is. The caller must hold energiesMutex
+func Derivatives() map[string][]float64 {
+	switch {
+	case useHessians:
+		return hessians
+	case useGradients:
+		return gradients
+	}
+	return nil
+}
+
+// ReadDerivative reads the gradient or Hessian in use from outfile
+func ReadDerivative(outfile string) ([]float64, error) {
+	if useHessians {
+		return Prog.(HessianProgram).ReadHessian(outfile)
+	}
+	return Prog.(GradientProgram).ReadGradient(outfile)
+}
+
+// journalDerivative journals the gradient or Hessian in use
+func journalDerivative(key string, v []float64) {
+	if useHessians {
+		JournalHessian(key, v)
+	} else {
+		JournalGradient(key, v)
+	}
+}
+
+// Component returns the position in the gradient or Hessian v of the
+// component used by job, which is Index[0] in a gradient and Index[0]
+// and Index[1] in a Hessian, along with its sign. If op is not nil,
+// v is for the geometry that op sends the one job is for to
+func Component(job Job, v []float64, op *SymOp) (int, float64) {
+	move := func(c int) (int, int) {
+		if op == nil {
+			return c, 1
+		}
+		return op.MoveCoord(c)
+	}
+	c, s := move(job.Index[0] - 1)
+	if !useHessians {
+		return c, float64(s)
+	}
+	n := int(math.Sqrt(float64(len(v))) + 0.5)
+	d, t := move(job.Index[1] - 1)
+	return c*n + d, float64(s * t)
+}
+
+// MoveDerivative returns the gradient or Hessian v for the geometry
+// that op sends the one v is for to
+func MoveDerivative(v []float64, op *SymOp) []float64 {
+	if op == nil {
+		return v
+	}
+	moved := make([]float64, len(v))
+	if !useHessians {
+		for c := range v {
+			m, s := op.MoveCoord(c)
+			moved[m] = float64(s) * v[c]
+		}
+		return moved
+	}
+	n := int(math.Sqrt(float64(len(v))) + 0.5)
+	for c := 0; c < n; c++ {
+		m, s := op.MoveCoord(c)
+		for d := 0; d < n; d++ {
+			p, t := op.MoveCoord(d)
+			moved[m*n+p] = float64(s*t) * v[c*n+d]
+		}
+	}
+	return moved
+}
+
+// ReadResult reads the Result of job from outfile. This is the
+// energy, or with UseDerivatives the gradient or Hessian component
+// given by Component, in which case the whole gradient or Hessian is
+// saved for RecordResult
+func ReadResult(job Job, outfile string) (float64, error) {
+	if !UseDerivatives() {
+		return Prog.ReadOut(outfile)
+	}
+	v, err := ReadDerivative(outfile)
+	if err != nil {
+		return brokenFloat, err
+	}
+	key, op := KeyOp(job.Steps)
+	energiesMutex.Lock()
+	unrecorded[key] = MoveDerivative(v, op)
+	energiesMutex.Unlock()
+	i, _ := Component(job, v, nil)
+	return v[i], nil
+}
+
+// StoredResult returns the Result of job from the finished energies,
+// or with UseDerivatives from the finished gradients or Hessians,
+// reporting whether there was one
+func StoredResult(job Job) (float64, bool) {
+	key, op := KeyOp(job.Steps)
+	energiesMutex.RLock()
+	defer energiesMutex.RUnlock()
+	if !UseDerivatives() {
+		energy, ok := energies[key]
+		return energy, ok
+	}
+	v, ok := Derivatives()[key]
+	if !ok {
+		return brokenFloat, false
+	}
+	i, s := Component(job, v, op)
+	return s * v[i], true
+}
+
+// RefDerivative reads the gradient or Hessian at the reference
+// geometry from outfile and stores it
+func RefDerivative(outfile string) error {
+	v, err := ReadDerivative(outfile)
+	if err != nil {
+		return err
+	}
+	energiesMutex.Lock()
+	Derivatives()[""] = v
+	energiesMutex.Unlock()
+	journalDerivative("", v)
+	return nil
+}
+
+// GradientBlock returns the gradient from the last block in lines
+// following a line containing header. The block has one row per atom,
+// starting with the atom number and ending with the three components,
+// and any column labels, dashes, or blank lines before the first row
+// are skipped. A block only counts once a blank line ends it, so one
+// that is still being written is not read
+func GradientBlock(lines []string, header string) (grad []float64, ok bool) {
+	var (
+		in   bool
+		rows []float64
+	)
+	for _, line := range lines {
+		if strings.Contains(line, header) {
+			in = true
+			rows = rows[:0]
+			continue
+		}
+		if !in {
+			continue
+		}
+		if row, isRow := gradientRow(line); isRow {
+			rows = append(rows, row...)
+			continue
+		}
+		switch {
+		case len(rows) == 0:
+		case line == "":
+			grad = append([]float64(nil), rows...)
+			ok = true
+			in = false
+		default:
+			in = false
+		}
+	}
+	return
+}
+
+// gradientRow returns the three components at the end of line if it
+// is a row of a gradient block
+func gradientRow(line string) ([]float64, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return nil, false
+	}
+	if _, err := strconv.Atoi(fields[0]); err != nil {
+		return nil, false
+	}
+	row := make([]float64, 3)
+	for i, f := range fields[len(fields)-3:] {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil, false
+		}
+		row[i] = v
+	}
+	return row, true
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestGradDerivative(t *testing.T) {
+	defer func(g bool) { useGradients = g }(useGradients)
+	useGradients = true
+	scale := 2 * delta / angbohr
+	tests := []struct {
+		msg   string
+		dims  []int
+		steps [][]int
+		coeff []float64
+	}{
+		{"second", []int{2, 1}, [][]int{{2}, {-2}}, []float64{1, -1}},
+		{"third", []int{3, 3, 1},
+			[][]int{{3, 3}, {}, {-3, -3}}, []float64{1, -2, 1}},
+		{"fourth", []int{1, 2, 3, 4}, [][]int{
+			{2, 3, 4}, {2, -3, 4}, {-2, 3, 4}, {-2, -3, 4},
+			{2, 3, -4}, {2, -3, -4}, {-2, 3, -4}, {-2, -3, -4},
+		}, []float64{1, -1, -1, 1, -1, 1, 1, -1}},
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			jobs := Derivative(test.dims...)
+			if len(jobs) != len(test.steps) {
+				t.Fatalf("got %d jobs, wanted %d", len(jobs), len(test.steps))
+			}
+			for i, job := range jobs {
+				if !reflect.DeepEqual(job.Steps, test.steps[i]) {
+					t.Errorf("%d: got steps %v, wanted %v",
+						i, job.Steps, test.steps[i])
+				}
+				if math.Abs(job.Coeff-test.coeff[i]*scale) > 1e-15 {
+					t.Errorf("%d: got coeff %v, wanted %v",
+						i, job.Coeff, test.coeff[i]*scale)
+				}
+				// the component is the smallest index
+				want := append([]int(nil), test.dims...)
+				sort.Ints(want)
+				if !reflect.DeepEqual(job.Index, want) {
+					t.Errorf("%d: got index %v, wanted %v", i, job.Index, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGradientBlock(t *testing.T) {
+	lines := []string{
+		"GRADIENT FOR STATE 1.1",
+		"",
+		"Atom dE/dx dE/dy dE/dz",
+		"",
+		"1 0.1 0.2 0.3",
+		"2 0.4 0.5 0.6",
+		"",
+	}
+	t.Run("complete", func(t *testing.T) {
+		got, ok := GradientBlock(lines, "GRADIENT FOR STATE")
+		want := []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6}
+		if !ok || !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, %v, wanted %v", got, ok, want)
+		}
+	})
+	t.Run("cut off", func(t *testing.T) {
+		cut := append(lines[:len(lines)-2:len(lines)-2], "2 0.4 0.")
+		if got, ok := GradientBlock(cut, "GRADIENT FOR STATE"); ok {
+			t.Errorf("got %v from a partial block", got)
+		}
+	})
+	t.Run("last block", func(t *testing.T) {
+		again := append(append([]string(nil), lines...),
+			"GRADIENT FOR STATE 1.1", "1 1 2 3", "2 4 5 6", "")
+		got, _ := GradientBlock(again, "GRADIENT FOR STATE")
+		want := []float64{1, 2, 3, 4, 5, 6}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, wanted %v", got, want)
+		}
+	})
+}
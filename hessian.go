@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// HessianProgram is implemented by Programs that can compute the
+// Cartesian Hessian in Hartree/bohr^2 in the same job as the energy.
+// It is returned flattened by rows
+type HessianProgram interface {
+	ReadHessian(filename string) ([]float64, error)
+}
+
+// hessianFile holds the finished Hessians at each checkpoint,
+// alongside snapshotFile
+const hessianFile = "hessians.json"
+
+// ErrHessianNotFound is returned by ReadHessian while a job has not
+// written its Hessian yet
+var ErrHessianNotFound = errors.New("Hessian not found in output")
+
+// TriangleBlock returns the symmetric matrix printed as a lower
+// triangle after the last line in lines containing header, flattened
+// by rows. The triangle is printed in blocks of columns, each starting
+// with a line of column labels and followed by one row for each
+// coordinate from the first column of the block on, which starts with
+// its own label. Blank lines around the blocks are skipped, and the
+// matrix only counts once every column has been read. Since its size
+// is only known at the end of the first block, it should only be read
+// from a finished output
+func TriangleBlock(lines []string, header string) ([]float64, bool) {
+	start := -1
+	for i, line := range lines {
+		if strings.Contains(line, header) {
+			start = i
+		}
+	}
+	if start < 0 {
+		return nil, false
+	}
+	var (
+		n     int // size of the matrix, once the first block is over
+		col   int // first column of the current block
+		width int // number of columns in the current block
+		row   int
+		tri   = make(map[[2]int]float64)
+	)
+	// finish the current block, reporting whether it was complete
+	finish := func() bool {
+		if n == 0 {
+			n = row
+		}
+		return row == n
+	}
+	for _, line := range lines[start+1:] {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		values := make([]float64, 0, len(fields)-1)
+		for _, f := range fields[1:] {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				break
+			}
+			values = append(values, v)
+		}
+		if len(values) == 0 {
+			// a new block of column labels
+			if width > 0 {
+				if !finish() || col+width >= n {
+					break
+				}
+				col += width
+			}
+			width = len(fields)
+			row = col
+			continue
+		}
+		want := row - col + 1
+		if want > width {
+			want = width
+		}
+		if width == 0 || len(values) != want {
+			return nil, false
+		}
+		for j, v := range values {
+			tri[[2]int{row, col + j}] = v
+		}
+		row++
+		if n > 0 && row == n && col+width >= n {
+			break
+		}
+	}
+	if width == 0 || !finish() || col+width < n {
+		return nil, false
+	}
+	hess := make([]float64, n*n)
+	for k, v := range tri {
+		hess[k[0]*n+k[1]] = v
+		hess[k[1]*n+k[0]] = v
+	}
+	return hess, true
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// waterHessian returns the Hessian printed in testfiles/molprofreq.out
+// and testfiles/gaussianfreq.fchk, flattened by rows
+func waterHessian() []float64 {
+	tri := []float64{
+		0.4194709,
+		0.0495832, 0.4337732,
+		0.0070560, -0.0264918, 0.3524199,
+		-0.0458083, -0.0479462, -0.0275343, 0.4058275,
+		-0.0315633, 0.0008407, 0.0328493, 0.0494084, 0.4427299,
+		0.0289220, 0.0483960, 0.0451086, 0.0206059, -0.0135880, 0.3586087,
+		0.0470365, 0.0250510, -0.0087163, -0.0383843, -0.0499995, -0.0380992,
+		0.3917198,
+		-0.0037576, -0.0349937, -0.0497718, -0.0411414, -0.0131616, 0.0210084,
+		0.0452977, 0.4482829,
+		-0.0490468, -0.0437726, -0.0179115, 0.0163737, 0.0429581, 0.0493386,
+		0.0325144, 0.0003982, 0.3680947,
+	}
+	const n = 9
+	hess := make([]float64, n*n)
+	k := 0
+	for r := 0; r < n; r++ {
+		for c := 0; c <= r; c++ {
+			hess[r*n+c] = tri[k]
+			hess[c*n+r] = tri[k]
+			k++
+		}
+	}
+	return hess
+}
+
+func TestHessDerivative(t *testing.T) {
+	defer func(h bool) { useHessians = h }(useHessians)
+	useHessians = true
+	scale := 4 * delta * delta / (angbohr * angbohr)
+	tests := []struct {
+		msg   string
+		dims  []int
+		steps [][]int
+		coeff []float64
+		index []int
+	}{
+		{"second", []int{2, 1}, [][]int{{}}, []float64{1}, []int{1, 2}},
+		{"third", []int{3, 1, 2}, [][]int{{3}, {-3}}, []float64{1, -1},
+			[]int{1, 2, 3}},
+		{"fourth", []int{1, 2, 3, 4}, [][]int{{3, 4}, {3, -4}, {-3, 4}, {-3, -4}},
+			[]float64{1, -1, -1, 1}, []int{1, 2, 3, 4}},
+		{"repeated", []int{1, 2, 2, 3}, [][]int{{2, 2}, {}, {-2, -2}},
+			[]float64{1, -2, 1}, []int{1, 3, 2, 2}},
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			jobs := Derivative(test.dims...)
+			if len(jobs) != len(test.steps) {
+				t.Fatalf("got %d jobs, wanted %d", len(jobs), len(test.steps))
+			}
+			for i, job := range jobs {
+				if !reflect.DeepEqual(job.Steps, test.steps[i]) {
+					t.Errorf("%d: got steps %v, wanted %v",
+						i, job.Steps, test.steps[i])
+				}
+				if math.Abs(job.Coeff-test.coeff[i]*scale) > 1e-15 {
+					t.Errorf("%d: got coeff %v, wanted %v",
+						i, job.Coeff, test.coeff[i]*scale)
+				}
+				if !reflect.DeepEqual(job.Index, test.index) {
+					t.Errorf("%d: got index %v, wanted %v",
+						i, job.Index, test.index)
+				}
+			}
+		})
+	}
+}
+
+func TestTriangleBlock(t *testing.T) {
+	const header = "Force Constants"
+	lines := []string{
+		"Force Constants",
+		"      G1    G2",
+		"G1   1.0",
+		"G2   2.0   3.0",
+		"G3   4.0   5.0",
+		"",
+		"      G3",
+		"G3   6.0",
+		"",
+	}
+	t.Run("complete", func(t *testing.T) {
+		got, ok := TriangleBlock(lines, header)
+		want := []float64{1, 2, 4, 2, 3, 5, 4, 5, 6}
+		if !ok || !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, %v, wanted %v", got, ok, want)
+		}
+	})
+	t.Run("cut off", func(t *testing.T) {
+		if got, ok := TriangleBlock(lines[:7], header); ok {
+			t.Errorf("got %v from a partial matrix", got)
+		}
+	})
+	t.Run("no header", func(t *testing.T) {
+		if got, ok := TriangleBlock(lines[1:], header); ok {
+			t.Errorf("got %v without a header", got)
+		}
+	})
+}
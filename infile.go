@@ -21,6 +21,15 @@ const (
 	BasisKey
 	ChargeKey
 	SpinKey
+	VariableKey
+	TemplateKey
+	JobTemplateKey
+	CompletionKey
+	BatchKey
+	ArrayKey
+	SymmetryKey
+	GradientKey
+	HessianKey
 	NumKeys
 )
 
@@ -37,6 +46,15 @@ func (k Key) String() string {
 		"BasisKey",
 		"ChargeKey",
 		"SpinKey",
+		"VariableKey",
+		"TemplateKey",
+		"JobTemplateKey",
+		"CompletionKey",
+		"BatchKey",
+		"ArrayKey",
+		"SymmetryKey",
+		"GradientKey",
+		"HessianKey",
 	}[k]
 }
 
@@ -66,6 +84,15 @@ func ParseInfile(filename string) map[Key]string {
 		Regexp{regexp.MustCompile(`(?i)basis=`), BasisKey},
 		Regexp{regexp.MustCompile(`(?i)charge=`), ChargeKey},
 		Regexp{regexp.MustCompile(`(?i)spin=`), SpinKey},
+		Regexp{regexp.MustCompile(`(?i)variable=`), VariableKey},
+		Regexp{regexp.MustCompile(`(?i)^template=`), TemplateKey},
+		Regexp{regexp.MustCompile(`(?i)^jobtemplate=`), JobTemplateKey},
+		Regexp{regexp.MustCompile(`(?i)completion=`), CompletionKey},
+		Regexp{regexp.MustCompile(`(?i)batch=`), BatchKey},
+		Regexp{regexp.MustCompile(`(?i)array=`), ArrayKey},
+		Regexp{regexp.MustCompile(`(?i)symmetry=`), SymmetryKey},
+		Regexp{regexp.MustCompile(`(?i)gradient=`), GradientKey},
+		Regexp{regexp.MustCompile(`(?i)hessian=`), HessianKey},
 	}
 	geom := regexp.MustCompile(`(?i)geometry={`)
 	for i := 0; i < len(lines); {
@@ -88,7 +115,14 @@ func ParseInfile(filename string) map[Key]string {
 			for _, kword := range Keywords {
 				if kword.MatchString(lines[i]) {
 					split := strings.Split(lines[i], "=")
-					keymap[kword.Name] = strings.ToUpper(split[len(split)-1])
+					value := split[len(split)-1]
+					// file names are case-sensitive
+					switch kword.Name {
+					case TemplateKey, JobTemplateKey:
+						keymap[kword.Name] = value
+					default:
+						keymap[kword.Name] = strings.ToUpper(value)
+					}
 				}
 			}
 			i++
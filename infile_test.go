@@ -23,3 +23,11 @@ func TestParseInfile(t *testing.T) {
 		t.Errorf("got %#v, wanted %#v\n", got, want)
 	}
 }
+
+func TestParseInfileTemplate(t *testing.T) {
+	got := ParseInfile("testfiles/template.in")[TemplateKey]
+	want := "testfiles/molpro.tmpl"
+	if got != want {
+		t.Errorf("got %q, wanted %q\n", got, want)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import "sync"
+
+// Geometries being computed, keyed by EnergyKey. Each channel is
+// closed once the energy for its key is in energies
+var (
+	inflight      = make(map[string]chan struct{})
+	inflightMutex sync.Mutex
+)
+
+// Claim reports whether the caller is the first to need the energy
+// for key and so should compute it. If not, it also returns a
+// channel that is closed once the energy is ready
+func Claim(key string) (chan struct{}, bool) {
+	inflightMutex.Lock()
+	defer inflightMutex.Unlock()
+	if done, ok := inflight[key]; ok {
+		return done, false
+	}
+	inflight[key] = make(chan struct{})
+	return nil, true
+}
+
+// Release wakes every Job following key, once its energy has been
+// recorded
+func Release(key string) {
+	inflightMutex.Lock()
+	defer inflightMutex.Unlock()
+	if done, ok := inflight[key]; ok {
+		close(done)
+		delete(inflight, key)
+	}
+}
+
+// Follow waits for done and records job with the energy computed
+// for the same geometry by another Job
+func Follow(job Job, done chan struct{}, ncoords, totalJobs int, wg *sync.WaitGroup) {
+	defer wg.Done()
+	<-done
+	job.Result, _ = StoredResult(job)
+	job.Status = "done"
+	RecordResult(job, ncoords, totalJobs)
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Files holding the finished energies. The snapshot is replaced
+// atomically by CompactJournal, and the journal holds every energy
+// finished since
+const (
+	journalFile  = "energies.journal"
+	snapshotFile = "energies.json"
+)
+
+// Shared state for the journal
+var (
+	journal      *os.File
+	journalMutex sync.Mutex
+)
+
+// OpenJournal opens filename for appending energies, emptying it
+// first unless keep is set. A kept journal is first cut back to its
+// last complete line, so that nothing is appended to one left torn
+// by a crash
+func OpenJournal(filename string, keep bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if !keep {
+		flags |= os.O_TRUNC
+	} else if data, err := ioutil.ReadFile(filename); err == nil {
+		if err := os.Truncate(filename, int64(bytes.LastIndexByte(data, '\n')+1)); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(filename, flags, 0644)
+	if err != nil {
+		return err
+	}
+	journalMutex.Lock()
+	journal = f
+	journalMutex.Unlock()
+	return nil
+}
+
+// JournalEnergy appends the energy for the geometry with StepKey key
+// to the journal as a single line and syncs it to disk before
+// returning. It does nothing if no journal is open
+func JournalEnergy(key string, energy float64) {
+	writeJournal(strconv.Quote(key) + " " + strconv.FormatFloat(energy, 'g', -1, 64) + "\n")
+}
+
+// JournalGradient is like JournalEnergy for a gradient, which is
+// written as a JSON array so that a line cut off by a crash does not
+// parse
+func JournalGradient(key string, grad []float64) {
+	data, err := json.Marshal(grad)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error writing journal:", err)
+		return
+	}
+	writeJournal(strconv.Quote(key) + " " + string(data) + "\n")
+}
+
+// JournalHessian is like JournalGradient for a flattened Hessian,
+// marked by the word hessian before the array
+func JournalHessian(key string, hess []float64) {
+	data, err := json.Marshal(hess)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error writing journal:", err)
+		return
+	}
+	writeJournal(strconv.Quote(key) + " hessian " + string(data) + "\n")
+}
+
+// writeJournal appends line to the journal and syncs it
+func writeJournal(line string) {
+	journalMutex.Lock()
+	defer journalMutex.Unlock()
+	if journal == nil {
+		return
+	}
+	if _, err := journal.WriteString(line); err != nil {
+		fmt.Fprintln(os.Stderr, "error writing journal:", err)
+		return
+	}
+	if err := journal.Sync(); err != nil {
+		fmt.Fprintln(os.Stderr, "error syncing journal:", err)
+	}
+}
+
+// ReplayJournal adds the energies in filename to energies, the
+// gradients to gradients, and the Hessians to hessians. Only lines
+// ending in a newline count, so one cut off by a crash is skipped even
+// if what was written of it still parses, and so is any other line
+// that cannot be parsed
+func ReplayJournal(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	reader := bufio.NewReader(f)
+	energiesMutex.Lock()
+	defer energiesMutex.Unlock()
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		i := strings.Index(line, " ")
+		if i < 0 {
+			continue
+		}
+		key, err := strconv.Unquote(line[:i])
+		if err != nil {
+			continue
+		}
+		if rest := strings.TrimPrefix(line[i+1:], "hessian "); strings.HasPrefix(rest, "[") {
+			var v []float64
+			if json.Unmarshal([]byte(rest), &v) != nil {
+				continue
+			}
+			if len(rest) < len(line[i+1:]) {
+				hessians[key] = v
+			} else {
+				gradients[key] = v
+			}
+			continue
+		}
+		energy, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			continue
+		}
+		energies[key] = energy
+	}
+}
+
+// WriteFileAtomic writes data to a temporary file next to filename,
+// syncs it, and renames it over filename, so that filename always
+// holds either its old contents or all of data
+func WriteFileAtomic(filename string, data []byte) error {
+	dir := filepath.Dir(filename)
+	tmp, err := ioutil.TempFile(dir, filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), filename); err != nil {
+		return err
+	}
+	// make the rename itself durable
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// CompactJournal atomically writes all of the energies to snapshot,
+// and any gradients and Hessians to gradientFile and hessianFile in
+// the same directory, and then empties the journal. Nothing is
+// journaled in between, and a crash before the journal is emptied
+// only leaves entries that are also in the snapshot
+func CompactJournal(snapshot string) error {
+	journalMutex.Lock()
+	defer journalMutex.Unlock()
+	dir := filepath.Dir(snapshot)
+	files := []struct {
+		name string
+		v    map[string][]float64
+	}{
+		{filepath.Join(dir, gradientFile), gradients},
+		{filepath.Join(dir, hessianFile), hessians},
+	}
+	energiesMutex.RLock()
+	data, err := json.Marshal(energies)
+	extra := make(map[string][]byte)
+	for _, file := range files {
+		if err == nil && len(file.v) > 0 {
+			extra[file.name], err = json.Marshal(file.v)
+		}
+	}
+	energiesMutex.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err = WriteFileAtomic(snapshot, data); err != nil {
+		return err
+	}
+	for name, data := range extra {
+		if err = WriteFileAtomic(name, data); err != nil {
+			return err
+		}
+	}
+	if journal == nil {
+		return nil
+	}
+	if err = journal.Truncate(0); err != nil {
+		return err
+	}
+	return journal.Sync()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	defer func() {
+		journal.Close()
+		journal = nil
+		energies = make(map[string]float64)
+		gradients = make(map[string][]float64)
+		hessians = make(map[string][]float64)
+	}()
+	tests := []struct {
+		msg   string
+		write func() // journals the entries in want
+		cut   string // a line cut off by a crash
+		after func() // journals more of want after resuming, if set
+		got   func() interface{}
+		want  interface{}
+		file  string // where CompactJournal writes them
+	}{
+		{"energy", func() {
+			JournalEnergy("", -76.369839620286)
+			JournalEnergy("1,-2", -76.369773027190)
+		}, `"1,1" -76.36`, nil,
+			func() interface{} { return energies },
+			map[string]float64{
+				"":     -76.369839620286,
+				"1,-2": -76.369773027190,
+			}, snapshotFile},
+		{"gradient", func() {
+			JournalGradient("", []float64{0, 0.0062, -0.0037})
+			JournalGradient("1,-2", []float64{0.001, 0.0061, -0.0036})
+		}, `"1,1" [0.002,0.006`, nil,
+			func() interface{} { return gradients },
+			map[string][]float64{
+				"":     {0, 0.0062, -0.0037},
+				"1,-2": {0.001, 0.0061, -0.0036},
+			}, gradientFile},
+		{"hessian", func() {
+			JournalHessian("", []float64{0.5, 0.1, 0.1, 0.4})
+			JournalHessian("1", []float64{0.51, 0.11, 0.11, 0.41})
+		}, `"-1" hessian [0.49,0.0`, nil,
+			func() interface{} { return hessians },
+			map[string][]float64{
+				"":  {0.5, 0.1, 0.1, 0.4},
+				"1": {0.51, 0.11, 0.11, 0.41},
+			}, hessianFile},
+		{"resume", func() {
+			JournalEnergy("", -76.369839620286)
+		}, `"1,1" -76.36`, func() {
+			JournalEnergy("1,-2", -76.369773027190)
+		},
+			func() interface{} { return energies },
+			map[string]float64{
+				"":     -76.369839620286,
+				"1,-2": -76.369773027190,
+			}, snapshotFile},
+	}
+	reset := func() {
+		energies = make(map[string]float64)
+		gradients = make(map[string][]float64)
+		hessians = make(map[string][]float64)
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := dir + "/" + journalFile
+			if err := OpenJournal(filename, false); err != nil {
+				t.Fatal(err)
+			}
+			defer journal.Close()
+			test.write()
+			f, _ := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0644)
+			f.WriteString(test.cut)
+			f.Close()
+			if test.after != nil {
+				journal.Close()
+				if err := OpenJournal(filename, true); err != nil {
+					t.Fatal(err)
+				}
+				test.after()
+			}
+			reset()
+			if err := ReplayJournal(filename); err != nil {
+				t.Fatal(err)
+			}
+			if got := test.got(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, wanted %v", got, test.want)
+			}
+			if err := CompactJournal(dir + "/" + snapshotFile); err != nil {
+				t.Fatal(err)
+			}
+			got, _ := ioutil.ReadFile(dir + "/" + test.file)
+			want, _ := json.Marshal(test.want)
+			if string(got) != string(want) {
+				t.Errorf("compacted %s, wanted %s", got, want)
+			}
+			if info, _ := os.Stat(filename); info.Size() != 0 {
+				t.Errorf("journal has %d bytes after compaction", info.Size())
+			}
+			// appending still works after truncation
+			test.write()
+			if test.after != nil {
+				test.after()
+			}
+			reset()
+			ReplayJournal(filename)
+			if got := test.got(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v after compaction, wanted %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Local implements the Submission interface by running job scripts
+// as child processes on the current machine
+type Local struct{}
+
+// Shared state for Local jobs
+var (
+	localProcs = runtime.NumCPU()
+	localSem   chan struct{}
+	localMutex sync.Mutex
+	localJobs  = make(map[int]chan struct{})
+	localCmds  = make(map[int]*exec.Cmd)
+	localCount int
+)
+
+// MakeHead returns the header for a local job script
+func (l Local) MakeHead() []string {
+	return []string{"#!/bin/sh"}
+}
+
+// MakeFoot returns the footer for a local job script. No signal is
+// needed since Wait reports completion directly
+func (l Local) MakeFoot(Sig1 int, dump *GarbageHeap) []string {
+	return []string{strings.Join(dump.Dump(), "\n")}
+}
+
+// Make uses MakeHead and MakeFoot to return the contents of a local
+// job script
+func (l Local) Make(filename string, Sig1 int, dump *GarbageHeap) []string {
+	if jobTemplate != nil {
+		return MakeJob(filename, Sig1, dump)
+	}
+	body := []string{Prog.Command(filename)}
+	if completion == "WATCH" {
+		body = append(body, "touch "+DoneFile(filename))
+	}
+	return MakeInput(l.MakeHead(), l.MakeFoot(Sig1, dump), body)
+}
+
+// Write uses Make to write the contents of a local job script to
+// filename
+func (l Local) Write(pbsfile, molprofile string, Sig1 int, dump *GarbageHeap) {
+	lines := l.Make(molprofile, Sig1, dump)
+	writelines := strings.Join(lines, "\n")
+	err := ioutil.WriteFile(pbsfile, []byte(writelines), 0755)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Submit starts filename as a child process once fewer than
+// localProcs jobs are running and returns a number identifying it
+// to Wait
+func (l Local) Submit(filename string) int {
+	localMutex.Lock()
+	if localSem == nil {
+		localSem = make(chan struct{}, localProcs)
+	}
+	localCount++
+	num := localCount
+	done := make(chan struct{})
+	localJobs[num] = done
+	localMutex.Unlock()
+	go func() {
+		localSem <- struct{}{}
+		localMutex.Lock()
+		// a job cancelled before it started has no entry left
+		_, ok := localJobs[num]
+		cmd := exec.Command("sh", filename)
+		// run each job in its own process group so that Cancel
+		// can kill the program along with the shell
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		if ok {
+			localCmds[num] = cmd
+			// errors show up in the output file, so leave them to
+			// ReadOut
+			cmd.Start()
+		}
+		localMutex.Unlock()
+		if ok {
+			cmd.Wait()
+		}
+		localMutex.Lock()
+		delete(localCmds, num)
+		localMutex.Unlock()
+		<-localSem
+		close(done)
+	}()
+	return num
+}
+
+// Wait blocks until the job numbered num finishes or timeout
+// elapses. Completion is only reported once, so waiting on a job
+// that has already been reported acts like a timeout
+func (l Local) Wait(num int, timeout time.Duration) error {
+	localMutex.Lock()
+	done, ok := localJobs[num]
+	localMutex.Unlock()
+	if !ok {
+		time.Sleep(timeout)
+		return ErrTimeout
+	}
+	select {
+	case <-done:
+		localMutex.Lock()
+		delete(localJobs, num)
+		localMutex.Unlock()
+		return nil
+	case <-time.After(timeout):
+		return ErrTimeout
+	}
+}
+
+// Status returns the state of each of ids without waiting. Jobs that
+// have already been reported by Wait are marked as JobVanished
+func (l Local) Status(ids []int) (map[int]JobState, error) {
+	states := make(map[int]JobState)
+	localMutex.Lock()
+	defer localMutex.Unlock()
+	for _, id := range ids {
+		done, ok := localJobs[id]
+		if !ok {
+			states[id] = JobVanished
+			continue
+		}
+		select {
+		case <-done:
+			states[id] = JobDone
+		default:
+			states[id] = JobRunning
+		}
+	}
+	return states, nil
+}
+
+// Cancel kills the running jobs in ids and keeps the rest from
+// starting. Cancelled jobs are no longer known to Wait or Status
+func (l Local) Cancel(ids []int) error {
+	localMutex.Lock()
+	defer localMutex.Unlock()
+	for _, id := range ids {
+		if cmd, ok := localCmds[id]; ok && cmd.Process != nil {
+			syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		}
+		delete(localJobs, id)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMakeLocal(t *testing.T) {
+	want := []string{
+		"#!/bin/sh",
+		"molpro -t 1 molpro.in",
+		"rm test1*\nrm test2*\nrm test3*"}
+	tdump := GarbageHeap{Heap: []string{"test1", "test2", "test3"}}
+	got := Local{}.Make("molpro.in", 35, &tdump)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, wanted %#v", got, want)
+	}
+}
+
+func TestLocalSubmit(t *testing.T) {
+	dir := t.TempDir()
+	script := dir + "/local.sh"
+	out := dir + "/local.out"
+	ioutil.WriteFile(script, []byte("#!/bin/sh\necho done > "+out), 0755)
+	l := Local{}
+	num := l.Submit(script)
+	if err := l.Wait(num, 5*time.Second); err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+	if _, err := os.Stat(out); os.IsNotExist(err) {
+		t.Errorf("%s does not exist", out)
+	}
+	t.Run("already reported", func(t *testing.T) {
+		err := l.Wait(num, 10*time.Millisecond)
+		if err != ErrTimeout {
+			t.Errorf("got %v, wanted %v", err, ErrTimeout)
+		}
+	})
+}
+
+// setPollInterval changes pollInterval under the lock the poller
+// reads it with
+func setPollInterval(d time.Duration) {
+	pollMutex.Lock()
+	pollInterval = d
+	pollMutex.Unlock()
+}
+
+func TestPollWait(t *testing.T) {
+	defer func(q Submission, i time.Duration) {
+		Queue = q
+		setPollInterval(i)
+	}(Queue, pollInterval)
+	Queue = Local{}
+	setPollInterval(10 * time.Millisecond)
+	dir := t.TempDir()
+	script := dir + "/poll.sh"
+	ioutil.WriteFile(script, []byte("#!/bin/sh\nsleep 0.1"), 0755)
+	num := Queue.Submit(script)
+	if err := PollWait(num, 10*time.Millisecond); err != ErrTimeout {
+		t.Errorf("got %v, wanted %v", err, ErrTimeout)
+	}
+	if err := PollWait(num, 5*time.Second); err != nil {
+		t.Errorf("got %v, wanted nil", err)
+	}
+	t.Run("vanished", func(t *testing.T) {
+		got, _ := Queue.Status([]int{-1})
+		want := map[int]JobState{-1: JobVanished}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, wanted %v", got, want)
+		}
+	})
+}
+
+// brokenQueue is a Local queue whose Status always fails
+type brokenQueue struct{ Local }
+
+func (b brokenQueue) Status(ids []int) (map[int]JobState, error) {
+	return nil, errors.New("broken")
+}
+
+func TestPollFallback(t *testing.T) {
+	defer func(q Submission, i time.Duration) {
+		Queue = q
+		setPollInterval(i)
+	}(Queue, pollInterval)
+	Queue = brokenQueue{}
+	setPollInterval(time.Millisecond)
+	if err := PollWait(-2, 5*time.Second); err != nil {
+		t.Errorf("got %v, wanted nil", err)
+	}
+}
+
+func TestQueueOutput(t *testing.T) {
+	tests := []struct {
+		msg    string
+		script string
+		benign []string
+		want   string
+		err    bool
+	}{
+		{"ok", "echo 1 R", nil, "1 R\n", false},
+		{"error", "echo oops >&2; exit 1", []string{"No job found"}, "", true},
+		{"no jobs", "echo No unfinished job found >&2; exit 255",
+			[]string{"No job found", "No unfinished job found"}, "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			out, err := QueueOutput(exec.Command("sh", "-c", test.script),
+				test.benign...)
+			if (err != nil) != test.err {
+				t.Errorf("got %v, wanted error: %v", err, test.err)
+			}
+			if string(out) != test.want {
+				t.Errorf("got %q, wanted %q", out, test.want)
+			}
+		})
+	}
+}
+
+func TestLocalCancel(t *testing.T) {
+	dir := t.TempDir()
+	script := dir + "/cancel.sh"
+	ioutil.WriteFile(script, []byte("#!/bin/sh\nsleep 30"), 0755)
+	l := Local{}
+	num := l.Submit(script)
+	// give the job a chance to start
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	if err := l.Cancel([]int{num}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := l.Status([]int{num})
+	if got[num] != JobVanished {
+		t.Errorf("got %v, wanted %v", got[num], JobVanished)
+	}
+	// the semaphore slot is only freed once the job is killed
+	for i := 0; i < localProcs; i++ {
+		l.Submit("/dev/null")
+	}
+	last := l.Submit("/dev/null")
+	if err := l.Wait(last, 5*time.Second); err != nil {
+		t.Errorf("got %v after %v, wanted nil", err, time.Since(start))
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LSF implements the Submission interface for IBM Spectrum LSF
+type LSF struct{}
+
+// MakeHead returns the header for an LSF input file
+func (l LSF) MakeHead() []string {
+	return []string{"#!/bin/sh",
+		"#BSUB -J go-cart",
+		"#BSUB -n 1",
+		"#BSUB -o /dev/null",
+		"#BSUB -e /dev/null",
+		"#BSUB -W 100:00",
+		`#BSUB -R "rusage[mem=9000]"`}
+}
+
+// MakeFoot returns the footer for an LSF input file. LSF sets
+// LSB_SUB_HOST to the host the job was submitted from, so the signal
+// goes back there
+func (l LSF) MakeFoot(Sig1 int, dump *GarbageHeap) []string {
+	foot := []string{strings.Join(dump.Dump(), "\n")}
+	if completion == "SIGNAL" {
+		sig1 := strconv.Itoa(Sig1)
+		foot = append([]string{"ssh -t $LSB_SUB_HOST pkill -" + sig1 + " " + progName}, foot...)
+	}
+	return foot
+}
+
+// Make uses MakeHead and MakeFoot to return the contents of an LSF
+// input file
+func (l LSF) Make(filename string, Sig1 int, dump *GarbageHeap) []string {
+	if jobTemplate != nil {
+		return MakeJob(filename, Sig1, dump)
+	}
+	body := []string{Prog.Command(filename)}
+	if completion == "WATCH" {
+		body = append(body, "touch "+DoneFile(filename))
+	}
+	return MakeInput(l.MakeHead(), l.MakeFoot(Sig1, dump), body)
+}
+
+// MakeArray returns the contents of an LSF array script with ntasks
+// tasks, each running its line of indexfile, with at most
+// concRoutines running at once. LSF makes an array by giving the job
+// name a range of indices
+func (l LSF) MakeArray(indexfile string, ntasks, Sig1 int, dump *GarbageHeap) []string {
+	head := l.MakeHead()
+	head[1] = `#BSUB -J "go-cart[1-` + strconv.Itoa(ntasks) + "]%" +
+		strconv.Itoa(concRoutines) + `"`
+	body := []string{`eval "$(sed -n "${LSB_JOBINDEX}p" ` + indexfile + `)"`}
+	return MakeInput(head, l.MakeFoot(Sig1, dump), body)
+}
+
+// Write uses Make to write the contents of an LSF input file to
+// filename
+func (l LSF) Write(pbsfile, molprofile string, Sig1 int, dump *GarbageHeap) {
+	lines := l.Make(molprofile, Sig1, dump)
+	writelines := strings.Join(lines, "\n")
+	err := ioutil.WriteFile(pbsfile, []byte(writelines), 0755)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// bsub runs bsub with filename as its standard input so that the
+// #BSUB directives are read
+func bsub(filename string) ([]byte, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	cmd := exec.Command("bsub")
+	cmd.Stdin = f
+	return cmd.Output()
+}
+
+// Submit runs bsub < filename
+func (l LSF) Submit(filename string) int {
+	out, err := bsub(filename)
+	for err != nil {
+		time.Sleep(time.Second)
+		out, err = bsub(filename)
+	}
+	return LSFJobID(string(out))
+}
+
+// LSFJobID returns the number of the job from the output of bsub,
+// like Job <1234> is submitted to default queue <normal>.
+func LSFJobID(out string) int {
+	start := strings.Index(out, "<")
+	end := strings.Index(out, ">")
+	if start < 0 || end < start {
+		return 0
+	}
+	i, _ := strconv.Atoi(out[start+1 : end])
+	return i
+}
+
+// Cancel runs bkill on all of ids
+func (l LSF) Cancel(ids []int) error {
+	args := make([]string, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, strconv.Itoa(id))
+	}
+	return exec.Command("bkill", args...).Run()
+}
+
+// Status runs bjobs once for all of the user's jobs and returns the
+// state of each of ids. Jobs bjobs no longer knows about are marked
+// as JobVanished. bjobs exits nonzero when the user has no jobs at
+// all, which just means that all of ids have vanished
+func (l LSF) Status(ids []int) (map[int]JobState, error) {
+	out, err := QueueOutput(exec.Command("bjobs", "-a", "-w", "-u", os.Getenv("USER")),
+		"No job found", "No unfinished job found")
+	if err != nil {
+		return nil, err
+	}
+	all := ParseBjobs(string(out))
+	states := make(map[int]JobState)
+	for _, id := range ids {
+		if state, ok := all[id]; ok {
+			states[id] = state
+		} else {
+			states[id] = JobVanished
+		}
+	}
+	return states, nil
+}
+
+// ParseBjobs returns the state of each job in the output of bjobs -a
+// -w. Array elements are listed separately under the same job ID,
+// and the array as a whole takes the state of its least finished
+// element
+func ParseBjobs(out string) map[int]JobState {
+	states := make(map[int]JobState)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		id, err := strconv.Atoi(fields[0])
+		if err != nil {
+			// header line
+			continue
+		}
+		var state JobState
+		switch fields[2] {
+		case "PEND", "PSUSP", "WAIT":
+			state = JobQueued
+		case "DONE":
+			state = JobDone
+		case "EXIT", "ZOMBI", "UNKWN":
+			state = JobFailed
+		default:
+			state = JobRunning
+		}
+		if old, ok := states[id]; !ok || state < old {
+			states[id] = state
+		}
+	}
+	return states
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestMakeLSF(t *testing.T) {
+	want := []string{
+		"#!/bin/sh",
+		"#BSUB -J go-cart",
+		"#BSUB -n 1",
+		"#BSUB -o /dev/null",
+		"#BSUB -e /dev/null",
+		"#BSUB -W 100:00",
+		`#BSUB -R "rusage[mem=9000]"`,
+		"molpro -t 1 molpro.in",
+		"ssh -t $LSB_SUB_HOST pkill -35 go-cart",
+		"rm test1*"}
+	tdump := GarbageHeap{Heap: []string{"test1"}}
+	got := LSF{}.Make("molpro.in", 35, &tdump)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwanted %#v", got, want)
+	}
+}
+
+func TestMakeLSFArray(t *testing.T) {
+	defer func(c int) { concRoutines = c }(concRoutines)
+	concRoutines = 50
+	tdump := GarbageHeap{Heap: []string{"test1"}}
+	got := LSF{}.MakeArray("inp/array.idx", 120, 35, &tdump)
+	if got[1] != `#BSUB -J "go-cart[1-120]%50"` {
+		t.Errorf("got %q for the job name", got[1])
+	}
+	body := `eval "$(sed -n "${LSB_JOBINDEX}p" inp/array.idx)"`
+	if got[7] != body {
+		t.Errorf("got %q, wanted %q", got[7], body)
+	}
+}
+
+func TestLSFJobID(t *testing.T) {
+	tests := []struct {
+		out  string
+		want int
+	}{
+		{"Job <6101> is submitted to default queue <normal>.\n", 6101},
+		{"Job <6200> is submitted to queue <short>.\n", 6200},
+		{"Request aborted by esub. Job not submitted.\n", 0},
+	}
+	for _, test := range tests {
+		if got := LSFJobID(test.out); got != test.want {
+			t.Errorf("LSFJobID(%q): got %d, wanted %d", test.out, got, test.want)
+		}
+	}
+}
+
+func TestParseBjobs(t *testing.T) {
+	out, _ := ioutil.ReadFile("testfiles/bjobs.out")
+	got := ParseBjobs(string(out))
+	want := map[int]JobState{
+		6101: JobRunning,
+		6102: JobQueued,
+		6103: JobDone,
+		6104: JobFailed,
+		6200: JobRunning,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"text/template"
 	"time"
 )
 
@@ -49,22 +50,33 @@ var (
 	ErrBlankOutput         = errors.New("Molpro output file exists but is blank")
 	ErrInputGeomNotFound   = errors.New("Geometry not found in input file")
 	ErrTimeout             = errors.New("Timeout waiting for signal")
+	ErrGradientNotFound    = errors.New("Gradient not found in output")
 )
 
 // Input parameters with default values
 var (
-	concRoutines int        = 5
-	nDerivative  int        = 4
-	Queue        Submission = PBS{}
-	checkAfter   int        = 100
-	Prog         Program    = Molpro{}
-	delta        float64    = 0.005
-	molproMethod string     = "CCSD(T)-F12"
-	mopacMethod  string     = "PM6"
-	basis        string     = "cc-pVTZ-F12"
-	charge       string     = "0"
-	spin         string     = "0"
-	energyLine              = regexp.MustCompile(`energy=`)
+	concRoutines   int        = 5
+	nDerivative    int        = 4
+	Queue          Submission = PBS{}
+	checkAfter     int        = 100
+	Prog           Program    = Molpro{}
+	delta          float64    = 0.005
+	molproMethod   string     = "CCSD(T)-F12"
+	mopacMethod    string     = "PM6"
+	psi4Method     string     = "ccsd(t)"
+	psi4Variable   string     = "CURRENT ENERGY"
+	orcaMethod     string     = "CCSD(T)"
+	gaussianMethod string     = "CCSD(T)"
+	cfourMethod    string     = "CCSD(T)"
+	xtbMethod      string     = "GFN2"
+	basis          string     = "cc-pVTZ-F12"
+	charge         string     = "0"
+	spin           string     = "0"
+	completion     string     = "SIGNAL"
+	batchSize      int        = 1
+	useArrays      bool       = false
+	useSymmetry    bool       = false
+	energyLine                = regexp.MustCompile(`energy=`)
 )
 
 // Shared variables
@@ -75,10 +87,13 @@ var (
 	brokenFloat         = math.NaN()
 	timeBeforeRetry     = time.Second * 15
 	workers         int = 0
+	workersMutex    sync.Mutex
+	checkMutex      sync.Mutex
 	fc2Mutex        sync.RWMutex
 	fc3Mutex        sync.RWMutex
 	fc4Mutex        sync.RWMutex
 	e2dMutex        sync.RWMutex
+	energiesMutex   sync.RWMutex
 	fc2CountMutex   sync.RWMutex
 	fc3CountMutex   sync.RWMutex
 	fc4CountMutex   sync.RWMutex
@@ -90,6 +105,7 @@ var (
 	fc3Done         []float64
 	fc4Done         []float64
 	e2dDone         [][]float64
+	energies        = make(map[string]float64)
 	fc2Count        [][]int
 	fc3Count        []int
 	fc4Count        []int
@@ -160,19 +176,57 @@ func Basename(filename string) string {
 	return file[:len(file)-len(path.Ext(file))]
 }
 
+// TrimExt returns filename with its extension removed, keeping any
+// leading path
+func TrimExt(filename string) string {
+	return filename[:len(filename)-len(path.Ext(filename))]
+}
+
+// JobFiles returns the names of the input file, queue script, and
+// output file for the job called name. Programs that need a scratch
+// directory for each job get one under inp/, while the rest share
+// inp/ directly
+func JobFiles(name string) (infile, pbsfile, outfile string) {
+	pbsfile = "inp/" + name + ".pbs"
+	if p, ok := Prog.(ScratchProgram); ok {
+		dir := "inp/" + name + "/"
+		return dir + p.InName(), pbsfile, dir + p.OutName()
+	}
+	return "inp/" + name + ".inp", pbsfile, "inp/" + name + ".out"
+}
+
 // GarbageHeap is a slice of Basenames to be deleted
 type GarbageHeap struct {
 	Heap []string // list of basenames
+	Dirs []string // list of scratch directories
+	mu   sync.Mutex
+}
+
+// Add adds the files for the job called name to the heap, including
+// its scratch directory if Prog uses one
+func (g *GarbageHeap) Add(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.Heap = append(g.Heap, "inp/"+name)
+	if _, ok := Prog.(ScratchProgram); ok {
+		g.Dirs = append(g.Dirs, "inp/"+name)
+	}
 }
 
 // Dump returns a slice of strings of files prefixed by "rm" for
 // deletion
 func (g *GarbageHeap) Dump() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	dump := make([]string, 0)
 	for _, v := range g.Heap {
 		dump = append(dump, "rm "+v+"*")
 	}
+	for _, v := range g.Dirs {
+		dump = append(dump, "rm -rf "+v)
+	}
 	g.Heap = []string{}
+	g.Dirs = []string{}
 	return dump
 }
 
@@ -205,6 +259,38 @@ func Step(coords []float64, steps ...int) []float64 {
 	return c
 }
 
+// StepKey returns a canonical key for the geometry reached by steps,
+// so that steps in any order, or ones that cancel, give the same key.
+// The reference geometry has the empty key
+func StepKey(steps []int) string {
+	net := make(map[int]int)
+	for _, v := range steps {
+		if v < 0 {
+			net[-v]--
+		} else {
+			net[v]++
+		}
+	}
+	coords := make([]int, 0, len(net))
+	for c, n := range net {
+		if n != 0 {
+			coords = append(coords, c)
+		}
+	}
+	sort.Ints(coords)
+	key := make([]string, 0, len(steps))
+	for _, c := range coords {
+		step := strconv.Itoa(c)
+		if net[c] < 0 {
+			step = "-" + step
+		}
+		for i := 0; i < IntAbs(net[c]); i++ {
+			key = append(key, step)
+		}
+	}
+	return strings.Join(key, ",")
+}
+
 // HashName returns a hashed filename
 func HashName() string {
 	var h maphash.Hash
@@ -250,18 +336,49 @@ func HandleSignal(sig int, timeout time.Duration) error {
 	}
 }
 
-// QueueAndWait submits a Job to the Queue and waits on the result
-func QueueAndWait(job Job, names []string, coords []float64, wg *sync.WaitGroup,
-	ch chan int, totalJobs int, dump *GarbageHeap, E0 float64) {
+// Await waits for job to finish or for timeout to elapse, polling the
+// Queue if completion is POLL, watching inp/ if it is WATCH, then
+// using the Queue's own notification if it has one and real-time
+// signals otherwise. Since inotify events arrive as soon as a job
+// finishes, timeout is replaced by the much longer watchTimeout when
+// watching and only guards against missed events
+func Await(job Job, timeout time.Duration) error {
+	switch completion {
+	case "POLL":
+		return PollWait(job.Number, timeout)
+	case "WATCH":
+		return WatchWait(job.Name, watchTimeout)
+	}
+	if w, ok := Queue.(Waiter); ok {
+		return w.Wait(job.Number, timeout)
+	}
+	return HandleSignal(job.Sig1, timeout)
+}
 
-	defer wg.Done()
+// CachedResult fills in the Result of job without running it if it
+// is the reference energy E0 or an energy already in energies or
+// e2d, reporting whether it did. With UseDerivatives, only the
+// gradients or Hessians are checked
+func CachedResult(job *Job, ncoords int, E0 float64) bool {
+	energy, ok := StoredResult(*job)
 	switch {
+	case UseDerivatives():
+		if ok {
+			job.Status = "done"
+			job.Result = energy
+		}
+		return ok
 	case job.Name == "E0":
 		job.Status = "done"
 		job.Result = E0
+		return true
+	case ok:
+		job.Status = "done"
+		job.Result = energy
+		return true
 	case len(job.Steps) == 2:
-		x := E2dIndex(job.Steps[0], len(coords))
-		y := E2dIndex(job.Steps[1], len(coords))
+		x := E2dIndex(job.Steps[0], ncoords)
+		y := E2dIndex(job.Steps[1], ncoords)
 		if x > y {
 			temp := x
 			x = y
@@ -270,40 +387,93 @@ func QueueAndWait(job Job, names []string, coords []float64, wg *sync.WaitGroup,
 		if e2d[x][y] != 0 {
 			job.Status = "done"
 			job.Result = e2d[x][y]
-			break
-		}
-		fallthrough
-	default:
-		coords = Step(coords, job.Steps...)
-		molprofile := "inp/" + job.Name + ".inp"
-		pbsfile := "inp/" + job.Name + ".pbs"
-		outfile := "inp/" + job.Name + ".out"
-		Prog.WriteIn(molprofile, names, coords)
-		Queue.Write(pbsfile, molprofile, job.Sig1, dump)
-		job.Number = Queue.Submit(pbsfile)
-		energy, err := Prog.ReadOut(outfile)
-		for err != nil {
-			HandleSignal(job.Sig1, timeBeforeRetry)
-			energy, err = Prog.ReadOut(outfile)
-			if err != nil {
-				fmt.Printf("error %s at step %d with %d workers\n",
-					err, progress, workers)
-				fmt.Println(outfile)
-			}
-			if (err == ErrEnergyNotParsed || err == ErrFinishedButNoEnergy ||
-				err == ErrFileContainsError || err == ErrBlankOutput) ||
-				(err == ErrFileNotFound && workers < concRoutines/2) {
-				fmt.Println("resubmitting for", err)
-				Queue.Submit(pbsfile)
-			}
+			return true
 		}
+	}
+	return false
+}
+
+// WriteJob writes the input file for job at its displaced geometry
+// and returns the names of its input, queue script, and output files
+func WriteJob(job Job, names []string, coords []float64) (infile, pbsfile, outfile string) {
+	infile, pbsfile, outfile = JobFiles(job.Name)
+	Prog.WriteIn(infile, names, Step(coords, job.Steps...))
+	return
+}
+
+// WaitResult waits for the submitted job to finish and returns its
+// Result from outfile, resubmitting pbsfile when the output shows
+// that the job failed
+func WaitResult(job *Job, pbsfile, outfile string) float64 {
+	energy, err := ReadResult(*job, outfile)
+	for err != nil {
+		werr := Await(*job, timeBeforeRetry)
+		energy, err = ReadResult(*job, outfile)
+		workersMutex.Lock()
+		busy := workers
+		workersMutex.Unlock()
 		if err != nil {
-			panic(err)
+			energiesMutex.RLock()
+			step := progress
+			energiesMutex.RUnlock()
+			fmt.Printf("error %s at step %d with %d workers\n",
+				err, step, busy)
+			fmt.Println(outfile)
 		}
-		job.Status = "done"
-		job.Result = energy
-		dump.Heap = append(dump.Heap, "inp/"+Basename(molprofile))
+		// when polling, a nil werr means the job has left the
+		// queue, so a missing output file will never show up
+		if Failed(err) ||
+			(err == ErrFileNotFound && busy < concRoutines/2) ||
+			(err == ErrFileNotFound && completion == "POLL" && werr == nil) {
+			fmt.Println("resubmitting for", err)
+			Forget(job.Number)
+			job.Number = Submit(pbsfile)
+		}
+	}
+	Forget(job.Number)
+	ClearDone(job.Name)
+	return energy
+}
+
+// Failed reports whether err from ReadOut means that the job has
+// finished without producing an energy and needs to be rerun
+func Failed(err error) bool {
+	return err == ErrEnergyNotParsed || err == ErrFinishedButNoEnergy ||
+		err == ErrFileContainsError || err == ErrBlankOutput
+}
+
+// RecordResult adds the Result of job to energies, or its gradient or
+// Hessian to Derivatives, and to the force constant arrays, wakes any
+// Jobs following it, and reports the progress
+func RecordResult(job Job, ncoords, totalJobs int) {
+	key := EnergyKey(job.Steps)
+	energiesMutex.Lock()
+	var seen bool
+	grad, fresh := unrecorded[key]
+	if UseDerivatives() {
+		// only the Job that ran leaves a gradient behind
+		seen = !fresh
+		if fresh {
+			Derivatives()[key] = grad
+			delete(unrecorded, key)
+		}
+	} else {
+		_, seen = energies[key]
+		energies[key] = job.Result
 	}
+	count := progress
+	if !seen {
+		progress++
+	}
+	energiesMutex.Unlock()
+	switch {
+	case seen:
+	case UseDerivatives():
+		journalDerivative(key, grad)
+	default:
+		JournalEnergy(key, job.Result)
+	}
+	Release(key)
 	// TODO should test something in here/DRY it up
 	// looks repetitive but not immediately clear how to fix
 	switch len(job.Index) {
@@ -311,8 +481,8 @@ func QueueAndWait(job Job, names []string, coords []float64, wg *sync.WaitGroup,
 	// fcDone doesn't need a lock because it's only written once per index
 	case 2:
 		if len(job.Steps) == 2 {
-			e2dx := E2dIndex(job.Steps[0], len(coords))
-			e2dy := E2dIndex(job.Steps[1], len(coords))
+			e2dx := E2dIndex(job.Steps[0], ncoords)
+			e2dy := E2dIndex(job.Steps[1], ncoords)
 			if e2dx > e2dy {
 				temp := e2dx
 				e2dx = e2dy
@@ -329,10 +499,12 @@ func QueueAndWait(job Job, names []string, coords []float64, wg *sync.WaitGroup,
 		fc2Mutex.Unlock()
 		fc2CountMutex.Lock()
 		fc2Count[x][y]--
-		fc2CountMutex.Unlock()
 		if fc2Count[x][y] == 0 {
+			fc2Mutex.RLock()
 			fc2Done[x][y] = fc2[x][y]
+			fc2Mutex.RUnlock()
 		}
+		fc2CountMutex.Unlock()
 	case 3:
 		sort.Ints(job.Index) // need to be in order, from spectro manual
 		index := Index3(job.Index[0], job.Index[1], job.Index[2])
@@ -341,10 +513,12 @@ func QueueAndWait(job Job, names []string, coords []float64, wg *sync.WaitGroup,
 		fc3Mutex.Unlock()
 		fc3CountMutex.Lock()
 		fc3Count[index]--
-		fc3CountMutex.Unlock()
 		if fc3Count[index] == 0 {
+			fc3Mutex.RLock()
 			fc3Done[index] = fc3[index]
+			fc3Mutex.RUnlock()
 		}
+		fc3CountMutex.Unlock()
 	case 4:
 		sort.Ints(job.Index)
 		index := Index4(job.Index[0], job.Index[1], job.Index[2], job.Index[3])
@@ -353,36 +527,74 @@ func QueueAndWait(job Job, names []string, coords []float64, wg *sync.WaitGroup,
 		fc4Mutex.Unlock()
 		fc4CountMutex.Lock()
 		fc4Count[index]--
-		fc4CountMutex.Unlock()
 		if fc4Count[index] == 0 {
+			fc4Mutex.RLock()
 			fc4Done[index] = fc4[index]
+			fc4Mutex.RUnlock()
 		}
+		fc4CountMutex.Unlock()
+	}
+	// only count each geometry once, no matter how many force
+	// constants use it
+	if seen {
+		return
 	}
-	fmt.Fprintf(os.Stderr, "%d/%d jobs completed (%.1f%%)\n", progress, totalJobs,
-		100*float64(progress)/float64(totalJobs))
-	progress++
-	if checkAfter > 0 && progress%checkAfter == 0 {
+	fmt.Fprintf(os.Stderr, "%d/%d jobs completed (%.1f%%)\n", count, totalJobs,
+		100*float64(count)/float64(totalJobs))
+	if checkAfter > 0 && (count+1)%checkAfter == 0 {
 		MakeCheckpoint()
 	}
+}
+
+// QueueAndWait submits a Job to the Queue and waits on the result
+func QueueAndWait(job Job, names []string, coords []float64, wg *sync.WaitGroup,
+	ch chan int, totalJobs int, dump *GarbageHeap, E0 float64) {
+
+	defer wg.Done()
+	if !CachedResult(&job, len(coords), E0) {
+		infile, pbsfile, outfile := WriteJob(job, names, coords)
+		Queue.Write(pbsfile, infile, job.Sig1, dump)
+		job.Number = Submit(pbsfile)
+		job.Result = WaitResult(&job, pbsfile, outfile)
+		job.Status = "done"
+		dump.Add(job.Name)
+	}
+	RecordResult(job, len(coords), totalJobs)
+	workersMutex.Lock()
 	workers--
+	workersMutex.Unlock()
 	<-ch
 }
 
 // RefEnergy is similar to QueueAndWait but specifically for the
 // initial reference geometry
 func RefEnergy(names []string, coords []float64, dump *GarbageHeap) (energy float64) {
-	molprofile := "inp/ref.inp"
-	pbsfile := "inp/ref.pbs"
-	outfile := "inp/ref.out"
+	molprofile, pbsfile, outfile := JobFiles("ref")
 	Prog.WriteIn(molprofile, names, coords)
 	Queue.Write(pbsfile, molprofile, 35, dump)
-	Queue.Submit(pbsfile)
-	energy, err := Prog.ReadOut(outfile)
+	job := Job{Name: "ref", Sig1: 35}
+	job.Number = Submit(pbsfile)
+	read := func() (float64, error) {
+		energy, err := Prog.ReadOut(outfile)
+		if err == nil && UseDerivatives() {
+			err = RefDerivative(outfile)
+		}
+		return energy, err
+	}
+	energy, err := read()
 	for err != nil {
-		HandleSignal(35, time.Second)
-		energy, err = Prog.ReadOut(outfile)
+		Await(job, time.Second)
+		energy, err = read()
 	}
-	dump.Heap = append(dump.Heap, "inp/"+Basename(molprofile))
+	Forget(job.Number)
+	ClearDone(job.Name)
+	// record it up front like RefDerivative does, so that the E0
+	// Jobs do not count it as a displaced geometry
+	energiesMutex.Lock()
+	energies[StepKey(nil)] = energy
+	energiesMutex.Unlock()
+	JournalEnergy(StepKey(nil), energy)
+	dump.Add("ref")
 	return
 }
 
@@ -440,15 +652,25 @@ func IntAbs(n int) int {
 	return n
 }
 
-// Drain takes a slice of Jobs and drains them individually into the
-// Queue
+// Drain takes a slice of Jobs and drains the ones whose geometries
+// are not already finished or running individually into the Queue,
+// or into the pending batch if batchSize is greater than one. With
+// useArrays, they are instead saved for SubmitArrays
 func Drain(jobs []Job, names []string, coords []float64, wg *sync.WaitGroup,
 	ch chan int, totalJobs int, dump *GarbageHeap, E0 float64) {
 
 	for job := range jobs {
-		wg.Add(1)
-		workers++
-		ch <- 1
+		// finished geometries are recorded right away, and ones
+		// already running are followed instead of run again
+		if CachedResult(&jobs[job], len(coords), E0) {
+			RecordResult(jobs[job], len(coords), totalJobs)
+			continue
+		}
+		if done, ok := Claim(EnergyKey(jobs[job].Steps)); !ok {
+			wg.Add(1)
+			go Follow(jobs[job], done, len(coords), totalJobs, wg)
+			continue
+		}
 		// this probably belongs in the job creation part
 		jobs[job].Sig1 = Sig1
 		// When they hit RTMAX roll over to RTMIN
@@ -457,49 +679,109 @@ func Drain(jobs []Job, names []string, coords []float64, wg *sync.WaitGroup,
 		} else {
 			Sig1++
 		}
+		if _, ok := Queue.(ArraySubmission); ok && useArrays {
+			level := len(jobs[job].Index)
+			arrayJobs[level] = append(arrayJobs[level], jobs[job])
+			continue
+		}
+		if batchSize > 1 {
+			batch = append(batch, jobs[job])
+			if len(batch) == batchSize {
+				FlushBatch(names, coords, wg, ch, totalJobs, dump, E0)
+			}
+			continue
+		}
+		wg.Add(1)
+		workersMutex.Lock()
+		workers++
+		workersMutex.Unlock()
+		ch <- 1
 		go QueueAndWait(jobs[job], names, coords, wg, ch, totalJobs, dump, E0)
 	}
 }
 
-// TotalJobs calculates the total number of jobs necessary for a given
-// quartic force field.  This is a very dumb implementation of
-// something that should have a formula
-func TotalJobs(nd, ncoords int) (total int) {
-	// 3 jobs for diagonal + 4 jobs for off diagonal
-	// totalJobs := ncoords*3 + (ncoords*ncoords-ncoords)*4
+// NeededKeys returns the EnergyKeys of every displaced geometry needed
+// for a force field of derivative level nd with ncoords coordinates.
+// The reference geometry is left out since RefEnergy computes it
+func NeededKeys(nd, ncoords int) map[string]bool {
+	keys := make(map[string]bool)
+	add := func(jobs []Job) {
+		for _, job := range jobs {
+			if key := EnergyKey(job.Steps); key != "" {
+				keys[key] = true
+			}
+		}
+	}
 	for i := 1; i <= ncoords; i++ {
 		for j := 1; j <= ncoords; j++ {
-			total += len(Derivative(i, j))
+			if i <= j {
+				add(Derivative(i, j))
+			}
 			if nd > 2 && j <= i {
 				for k := 1; k <= j; k++ {
-					total += len(Derivative(i, j, k))
+					add(Derivative(i, j, k))
 					if nd > 3 {
 						for l := 1; l <= k; l++ {
-							total += len(Derivative(i, j, k, l))
+							add(Derivative(i, j, k, l))
 						}
 					}
 				}
 			}
 		}
 	}
-	return
+	return keys
+}
+
+// TotalJobs calculates the total number of jobs necessary for a given
+// quartic force field, running each unique geometry once
+func TotalJobs(nd, ncoords int) int {
+	return len(NeededKeys(nd, ncoords))
 }
 
 // MakeCheckpoint marshals the necessary data structures into JSON for
-// saving checkpoints and writes them to the checkpoint files
+// saving checkpoints and writes them to the checkpoint files. Each
+// file is replaced atomically, and the energies are compacted from
+// the journal into their snapshot
 func MakeCheckpoint() {
-	fc2JSON, _ := json.Marshal(fc2Done)
-	ioutil.WriteFile("fc2.json", fc2JSON, 0755)
-	fc3JSON, _ := json.Marshal(fc3Done)
-	ioutil.WriteFile("fc3.json", fc3JSON, 0755)
-	fc4JSON, _ := json.Marshal(fc4Done)
-	ioutil.WriteFile("fc4.json", fc4JSON, 0755)
-	e2dJSON, _ := json.Marshal(e2d)
-	ioutil.WriteFile("e2d.json", e2dJSON, 0755)
+	// the interrupt handler can write one at the same time as a
+	// finishing job
+	checkMutex.Lock()
+	defer checkMutex.Unlock()
+	files := []struct {
+		name string
+		v    interface{}
+	}{
+		{"fc2.json", fc2Done},
+		{"fc3.json", fc3Done},
+		{"fc4.json", fc4Done},
+		{"e2d.json", e2d},
+	}
+	for _, file := range files {
+		// the fcNDone arrays are filled under the count locks
+		fc2CountMutex.RLock()
+		fc3CountMutex.RLock()
+		fc4CountMutex.RLock()
+		e2dMutex.RLock()
+		data, err := json.Marshal(file.v)
+		e2dMutex.RUnlock()
+		fc4CountMutex.RUnlock()
+		fc3CountMutex.RUnlock()
+		fc2CountMutex.RUnlock()
+		if err == nil {
+			err = WriteFileAtomic(file.name, data)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error writing %s: %s\n", file.name, err)
+		}
+	}
+	if err := CompactJournal(snapshotFile); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing %s: %s\n", snapshotFile, err)
+	}
 }
 
 // ReadCheckpoint restores the force constant and useful second
-// derivative arrays from the JSON checkpoint files
+// derivative arrays and the finished energies from the JSON
+// checkpoint files
 func ReadCheckpoint() {
 	fc2lines, _ := ioutil.ReadFile("fc2.json")
 	fc3lines, _ := ioutil.ReadFile("fc3.json")
@@ -516,6 +798,28 @@ func ReadCheckpoint() {
 	if err != nil {
 		panic(err)
 	}
+	// older checkpoints have no energies
+	energieslines, err := ioutil.ReadFile(snapshotFile)
+	if err == nil {
+		err = json.Unmarshal(energieslines, &energies)
+		if err != nil {
+			panic(err)
+		}
+	}
+	gradientlines, err := ioutil.ReadFile(gradientFile)
+	if err == nil {
+		err = json.Unmarshal(gradientlines, &gradients)
+		if err != nil {
+			panic(err)
+		}
+	}
+	hessianlines, err := ioutil.ReadFile(hessianFile)
+	if err == nil {
+		err = json.Unmarshal(hessianlines, &hessians)
+		if err != nil {
+			panic(err)
+		}
+	}
 }
 
 // SetParams uses the parsed input file values to set global
@@ -538,6 +842,12 @@ func SetParams(filename string) (names []string, coords []float64, err error) {
 				Queue = PBS{}
 			case "SLURM":
 				Queue = Slurm{}
+			case "LOCAL":
+				Queue = Local{}
+			case "SGE":
+				Queue = SGE{}
+			case "LSF":
+				Queue = LSF{}
 			}
 		case ChkIntervalKey:
 			checkAfter, err = strconv.Atoi(value)
@@ -550,6 +860,18 @@ func SetParams(filename string) (names []string, coords []float64, err error) {
 			case "CCCR":
 				Prog = CcCR{}
 				energyLine = regexp.MustCompile(`^\s+CCCRE\s+=`)
+			case "PSI4":
+				Prog = Psi4{}
+			case "ORCA":
+				Prog = Orca{}
+			case "GAUSSIAN":
+				Prog = Gaussian{}
+			case "CFOUR":
+				Prog = CFour{}
+			case "XTB":
+				Prog = XTB{}
+			case "ANALYTIC":
+				Prog = Analytic{}
 			}
 		case GeomKey:
 			lines := strings.Split(value, "\n")
@@ -559,6 +881,35 @@ func SetParams(filename string) (names []string, coords []float64, err error) {
 		case MethodKey:
 			molproMethod = value
 			mopacMethod = value
+			psi4Method = value
+			orcaMethod = value
+			gaussianMethod = value
+			cfourMethod = value
+			xtbMethod = value
+		case VariableKey:
+			psi4Variable = value
+		case CompletionKey:
+			completion = value
+		case BatchKey:
+			batchSize, err = strconv.Atoi(value)
+		case ArrayKey:
+			useArrays, err = strconv.ParseBool(value)
+		case SymmetryKey:
+			useSymmetry, err = strconv.ParseBool(value)
+		case GradientKey:
+			useGradients, err = strconv.ParseBool(value)
+		case HessianKey:
+			useHessians, err = strconv.ParseBool(value)
+		case TemplateKey:
+			inputTemplate, err = template.ParseFiles(value)
+			if err != nil {
+				return
+			}
+		case JobTemplateKey:
+			jobTemplate, err = template.ParseFiles(value)
+			if err != nil {
+				return
+			}
 		case BasisKey:
 			basis = value
 		case ChargeKey:
@@ -616,6 +967,80 @@ func InitFCArrays(ncoords int) (int, int) {
 	return other3, other4
 }
 
+// RunJobs drains the Jobs for every force constant up to
+// nDerivative into the Queue and waits for all of them to finish,
+// filling in the lower triangle of fc2 at the end
+func RunJobs(names []string, coords []float64, dump *GarbageHeap, E0 float64) {
+	var wg sync.WaitGroup
+	ncoords := len(coords)
+	ch := make(chan int, concRoutines)
+
+	// only count the geometries that are not already finished
+	totalJobs := 0
+	energiesMutex.RLock()
+	for key := range NeededKeys(nDerivative, ncoords) {
+		_, ok := energies[key]
+		if d := Derivatives(); d != nil {
+			_, ok = d[key]
+		}
+		if !ok {
+			totalJobs++
+		}
+	}
+	energiesMutex.RUnlock()
+	for i := 1; i <= ncoords; i++ {
+		for j := 1; j <= ncoords; j++ {
+			// fc2 is symmetric, so only the upper triangle is run
+			if i <= j && fc2Done[i-1][j-1] == 0 {
+				jobs := Derivative(i, j)
+				fc2Count[i-1][j-1] = len(jobs)
+				Drain(jobs, names, coords, &wg, ch, totalJobs, dump, E0)
+			}
+			if nDerivative > 2 && j <= i {
+				for k := 1; k <= j; k++ {
+					// Index3/4 require arguments to be sorted
+					temp := []int{i, j, k}
+					sort.Ints(temp)
+					index := Index3(temp[0], temp[1], temp[2])
+					if fc3Done[index] == 0 {
+						jobs := Derivative(i, j, k)
+						fc3Count[index] = len(jobs)
+						Drain(jobs, names, coords, &wg, ch, totalJobs, dump, E0)
+					}
+					if nDerivative > 3 {
+						for l := 1; l <= k; l++ {
+							temp := []int{i, j, k, l}
+							sort.Ints(temp)
+							index := Index4(temp[0], temp[1], temp[2], temp[3])
+							if fc4Done[index] == 0 {
+								jobs := Derivative(i, j, k, l)
+								fc4Count[index] = len(jobs)
+								Drain(jobs, names, coords, &wg, ch, totalJobs, dump, E0)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	FlushBatch(names, coords, &wg, ch, totalJobs, dump, E0)
+	SubmitArrays(names, coords, &wg, totalJobs, dump, E0)
+	wg.Wait()
+	MirrorFC2()
+}
+
+// MirrorFC2 copies the upper triangle of fc2 and fc2Done into the
+// lower triangle
+func MirrorFC2() {
+	for i := range fc2 {
+		for j := i + 1; j < len(fc2); j++ {
+			fc2[j][i] = fc2[i][j]
+			fc2Done[j][i] = fc2Done[i][j]
+		}
+	}
+}
+
 func main() {
 
 	var (
@@ -623,7 +1048,6 @@ func main() {
 		coords  []float64
 		ncoords int
 		natoms  int
-		wg      sync.WaitGroup
 		dump    GarbageHeap
 		err     error
 	)
@@ -642,6 +1066,16 @@ func main() {
 		}
 	}
 
+	if _, ok := Prog.(GradientProgram); useGradients && !ok {
+		panic("Program cannot compute gradients, set gradient=false")
+	}
+	if _, ok := Prog.(HessianProgram); useHessians && !ok {
+		panic("Program cannot compute Hessians, set hessian=false")
+	}
+	if useGradients && useHessians {
+		panic("Only one of gradient and hessian can be set")
+	}
+
 	if _, err := os.Stat("inp/"); os.IsNotExist(err) {
 		os.Mkdir("inp", 0755)
 	} else {
@@ -653,59 +1087,56 @@ func main() {
 		}
 	}
 
-	other3, other4 := InitFCArrays(ncoords)
+	HandleInterrupt()
 
-	if *checkpoint {
-		ReadCheckpoint()
+	if useSymmetry {
+		var rotated bool
+		coords, rotated = Orient(names, coords, symTol)
+		if rotated {
+			fmt.Println("geometry rotated to put its symmetry elements along the axes:")
+			for i := range names {
+				fmt.Printf("%s %.10f %.10f %.10f\n", names[i],
+					coords[3*i], coords[3*i+1], coords[3*i+2])
+			}
+		}
+		symOps = FindAxisSymmetry(names, coords, symTol)
+		// operations mixing the axes cannot be used, so this may be
+		// a subgroup of the full point group
+		fmt.Printf("symmetry along the axes: %s, using %d operations\n",
+			AxisGroup(symOps), len(symOps))
 	}
 
-	E0 := RefEnergy(names, coords, &dump)
+	if completion == "WATCH" {
+		if err := Watch("inp"); err != nil {
+			panic(err)
+		}
+	}
 
-	ch := make(chan int, concRoutines)
+	other3, other4 := InitFCArrays(ncoords)
 
-	totalJobs := TotalJobs(nDerivative, ncoords)
-	for i := 1; i <= ncoords; i++ {
-		for j := 1; j <= ncoords; j++ {
-			if fc2Done[i-1][j-1] == 0 {
-				jobs := Derivative(i, j)
-				fc2Count[i-1][j-1] = len(jobs)
-				Drain(jobs, names, coords, &wg, ch, totalJobs, &dump, E0)
-			} else {
-				progress++
-			}
-			if nDerivative > 2 && j <= i {
-				for k := 1; k <= j; k++ {
-					// Index3/4 require arguments to be sorted
-					temp := []int{i, j, k}
-					sort.Ints(temp)
-					index := Index3(temp[0], temp[1], temp[2])
-					if fc3Done[index] == 0 {
-						jobs := Derivative(i, j, k)
-						fc3Count[index] = len(jobs)
-						Drain(jobs, names, coords, &wg, ch, totalJobs, &dump, E0)
-					} else {
-						progress++
-					}
-					if nDerivative > 3 {
-						for l := 1; l <= k; l++ {
-							temp := []int{i, j, k, l}
-							sort.Ints(temp)
-							index := Index4(temp[0], temp[1], temp[2], temp[3])
-							if fc4Done[index] == 0 {
-								jobs := Derivative(i, j, k, l)
-								fc4Count[index] = len(jobs)
-								Drain(jobs, names, coords, &wg, ch, totalJobs, &dump, E0)
-							} else {
-								progress++
-							}
-						}
-					}
-				}
-			}
+	if *checkpoint {
+		ReadCheckpoint()
+		// energies finished since the last checkpoint
+		err := ReplayJournal(journalFile)
+		if err != nil && !os.IsNotExist(err) {
+			panic(err)
 		}
 	}
+	if err := OpenJournal(journalFile, *checkpoint); err != nil {
+		panic(err)
+	}
 
-	wg.Wait()
+	// the reference energy may already be in the checkpoint
+	E0, ok := energies[StepKey(nil)]
+	if d := Derivatives(); d != nil {
+		// only the gradient or Hessian there is used
+		_, ok = d[StepKey(nil)]
+	}
+	if !ok {
+		E0 = RefEnergy(names, coords, &dump)
+	}
+
+	RunJobs(names, coords, &dump, E0)
 
 	PrintFile15(fc2, natoms, "fort.15")
 	if nDerivative > 2 {
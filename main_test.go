@@ -323,21 +323,21 @@ func TestIntAbs(t *testing.T) {
 func TestTotalJobs(t *testing.T) {
 	t.Run("2nd derivative, water", func(t *testing.T) {
 		got := TotalJobs(2, 9)
-		want := 315
+		want := 162
 		if got != want {
 			t.Errorf("got %d, wanted %d\n", got, want)
 		}
 	})
 	t.Run("3rd derivative, water", func(t *testing.T) {
 		got := TotalJobs(3, 9)
-		want := 1455
+		want := 1158
 		if got != want {
 			t.Errorf("got %d, wanted %d\n", got, want)
 		}
 	})
 	t.Run("4th derivative, water", func(t *testing.T) {
 		got := TotalJobs(4, 9)
-		want := 7440
+		want := 5640
 		if got != want {
 			t.Errorf("got %d, wanted %d\n", got, want)
 		}
@@ -347,6 +347,10 @@ func TestTotalJobs(t *testing.T) {
 // TODO Make/ReadCheckpoint
 
 func TestSetParams(t *testing.T) {
+	// restore the defaults for the other tests
+	defer func(c, n int, q Submission, a int, p Program, d float64) {
+		concRoutines, nDerivative, Queue, checkAfter, Prog, delta = c, n, q, a, p, d
+	}(concRoutines, nDerivative, Queue, checkAfter, Prog, delta)
 	wantBefore := concRoutines == 5 && nDerivative == 4 && Queue == PBS{} &&
 		checkAfter == 100 && Prog == Molpro{} && delta == 0.005
 	if !wantBefore {
@@ -361,3 +365,46 @@ func TestSetParams(t *testing.T) {
 }
 
 // TODO InitFCArrays
+
+func TestStepKey(t *testing.T) {
+	tests := []struct {
+		steps []int
+		want  string
+	}{
+		{[]int{}, ""},
+		{[]int{1, -1}, ""},
+		{[]int{2, 1}, "1,2"},
+		{[]int{1, 2}, "1,2"},
+		{[]int{-3, 1, 1}, "1,1,-3"},
+		{[]int{1, -3, 1}, "1,1,-3"},
+		{[]int{-2, -2, 2, 4}, "-2,4"},
+	}
+	for _, test := range tests {
+		if got := StepKey(test.steps); got != test.want {
+			t.Errorf("StepKey(%v): got %q, wanted %q", test.steps, got, test.want)
+		}
+	}
+}
+
+func TestMirrorFC2(t *testing.T) {
+	defer func(f, d [][]float64) { fc2, fc2Done = f, d }(fc2, fc2Done)
+	fc2 = [][]float64{
+		{1, 2, 3},
+		{0, 4, 5},
+		{0, 0, 6}}
+	fc2Done = [][]float64{
+		{1, 2, 3},
+		{0, 4, 5},
+		{0, 0, 6}}
+	want := [][]float64{
+		{1, 2, 3},
+		{2, 4, 5},
+		{3, 5, 6}}
+	MirrorFC2()
+	if !reflect.DeepEqual(fc2, want) {
+		t.Errorf("got %v, wanted %v", fc2, want)
+	}
+	if !reflect.DeepEqual(fc2Done, want) {
+		t.Errorf("got %v, wanted %v for fc2Done", fc2Done, want)
+	}
+}
@@ -8,28 +8,43 @@ import (
 	"strings"
 )
 
+// molproForceConstants heads the Hessian printed by frequencies
+const molproForceConstants = "Force Constants (Second Derivatives of the Energy)"
+
 // Molpro implements the Program interface
 type Molpro struct{}
 
-// MakeHead makes a header for a Molpro input file
+// MakeHead makes a header for a Molpro input file. With
+// UseDerivatives, the molecule is kept in its input orientation so
+// the gradient or Hessian is in the same frame as the displacements
 func (m Molpro) MakeHead() []string {
-	return []string{"memory,1125,m",
+	head := []string{"memory,1125,m",
 		"gthresh,energy=1.d-10,zero=1.d-16,oneint=1.d-16,twoint=1.d-16;",
 		"gthresh,optgrad=1.d-8,optstep=1.d-8;",
 		"nocompress",
 		"geomtyp=xyz",
-		"angstrom",
-		"geometry={"}
+		"angstrom"}
+	if UseDerivatives() {
+		head = append(head, "orient,noorient", "symmetry,nosym")
+	}
+	return append(head, "geometry={")
 }
 
 // MakeFoot makes a footer for a Molpro input file
 func (m Molpro) MakeFoot() []string {
-	return []string{"}",
+	foot := []string{"}",
 		"basis=" + basis,
 		"set,charge=" + charge,
 		"set,spin=" + spin,
 		"hf,accuracy=16,energy=1.0d-10",
 		"{" + molproMethod + ",thrden=1.0d-8,thrvar=1.0d-10}"}
+	switch {
+	case useGradients:
+		foot = append(foot, "forces")
+	case useHessians:
+		foot = append(foot, "frequencies")
+	}
+	return foot
 }
 
 // MakeIn makes a Molpro input file
@@ -44,6 +59,9 @@ func (m Molpro) MakeIn(names []string, coords []float64) []string {
 		}
 		body = append(body, strings.Join(tmp, " "))
 	}
+	if inputTemplate != nil {
+		return TemplateIn(body, molproMethod)
+	}
 	return MakeInput(m.MakeHead(), m.MakeFoot(), body)
 }
 
@@ -58,6 +76,11 @@ func (m Molpro) WriteIn(filename string, names []string, coords []float64) {
 	}
 }
 
+// Command returns the command for running Molpro on filename
+func (m Molpro) Command(filename string) string {
+	return "molpro -t 1 " + filename
+}
+
 // ReadOut reads a Molpro output file and returns the resulting energy
 func (m Molpro) ReadOut(filename string) (result float64, err error) {
 	runtime.LockOSThread()
@@ -110,3 +133,63 @@ func (m Molpro) ReadOut(filename string) (result float64, err error) {
 	runtime.UnlockOSThread()
 	return result, err
 }
+
+// ReadGradient reads the gradient printed by forces in a Molpro
+// output file
+func (m Molpro) ReadGradient(filename string) ([]float64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return nil, ErrFileNotFound
+	}
+	lines, _ := ReadFile(filename)
+	if len(lines) == 1 {
+		return nil, ErrBlankOutput
+	}
+	finished := false
+	for _, line := range lines {
+		if strings.Contains(strings.ToUpper(line), "ERROR") {
+			return nil, ErrFileContainsError
+		}
+		if strings.Contains(line, molproTerminated) {
+			finished = true
+		}
+	}
+	if grad, ok := GradientBlock(lines, "GRADIENT FOR STATE"); ok {
+		return grad, nil
+	}
+	if finished {
+		return nil, ErrFinishedButNoEnergy
+	}
+	return nil, ErrGradientNotFound
+}
+
+// ReadHessian reads the force constants printed by frequencies in a
+// finished Molpro output file
+func (m Molpro) ReadHessian(filename string) ([]float64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return nil, ErrFileNotFound
+	}
+	lines, _ := ReadFile(filename)
+	if len(lines) == 1 {
+		return nil, ErrBlankOutput
+	}
+	finished := false
+	for _, line := range lines {
+		if strings.Contains(strings.ToUpper(line), "ERROR") {
+			return nil, ErrFileContainsError
+		}
+		if strings.Contains(line, molproTerminated) {
+			finished = true
+		}
+	}
+	if !finished {
+		return nil, ErrHessianNotFound
+	}
+	if hess, ok := TriangleBlock(lines, molproForceConstants); ok {
+		return hess, nil
+	}
+	return nil, ErrFinishedButNoEnergy
+}
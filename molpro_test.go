@@ -75,3 +75,55 @@ func TestReadMolproOut(t *testing.T) {
 		}
 	})
 }
+
+func TestReadMolproGradient(t *testing.T) {
+	tests := []struct {
+		msg      string
+		filename string
+		want     []float64
+		err      error
+	}{
+		{"gradient", "testfiles/molprograd.out", []float64{
+			0, 0.006264396, -0.003728340,
+			0, 0, 0.007456681,
+			0, -0.006264396, -0.003728340,
+		}, nil},
+		{"no output file", "testfiles/molprograd1.out", nil, ErrFileNotFound},
+		{"finished but no gradient", "testfiles/molpro.out", nil, ErrFinishedButNoEnergy},
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			got, err := TestProg.ReadGradient(test.filename)
+			if err != test.err {
+				t.Errorf("got error %v, wanted %v", err, test.err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, wanted %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadMolproHessian(t *testing.T) {
+	tests := []struct {
+		msg      string
+		filename string
+		want     []float64
+		err      error
+	}{
+		{"hessian", "testfiles/molprofreq.out", waterHessian(), nil},
+		{"no output file", "testfiles/molprofreq1.out", nil, ErrFileNotFound},
+		{"finished but no hessian", "testfiles/molpro.out", nil, ErrFinishedButNoEnergy},
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			got, err := TestProg.ReadHessian(test.filename)
+			if err != test.err {
+				t.Errorf("got error %v, wanted %v", err, test.err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, wanted %v", got, test.want)
+			}
+		})
+	}
+}
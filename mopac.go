@@ -8,6 +8,22 @@ import (
 	"strings"
 )
 
+const (
+	kcalHartree = 627.5091809
+	mopacDone   = "== MOPAC DONE =="
+	mopacHeat   = "HEAT_OF_FORMATION:KCAL/MOL="
+	mopacAuxEnd = "END OF MOPAC FILE"
+)
+
+// mopacErrors are the strings indicating that a Mopac calculation
+// failed, including a missing external parameter file and SCF
+// convergence failure
+var mopacErrors = []string{
+	"ERROR",
+	"DOES NOT EXIST",
+	"UNABLE TO ACHIEVE SELF-CONSISTENCE",
+}
+
 // Mopac implements the Program interface
 type Mopac struct{}
 
@@ -37,6 +53,9 @@ func (m Mopac) MakeIn(names []string, coords []float64) (inputLines []string) {
 		}
 		body = append(body, strings.Join(tmp, " "))
 	}
+	if inputTemplate != nil {
+		return TemplateIn(body, mopacMethod)
+	}
 	return MakeInput(m.MakeHead(), m.MakeFoot(), body)
 }
 
@@ -50,19 +69,64 @@ func (m Mopac) WriteIn(filename string, names []string, coords []float64) {
 	}
 }
 
-// ReadOut reads a Mopac output file and returns the resulting energy
+// Command returns the command for running Mopac on filename
+func (m Mopac) Command(filename string) string {
+	return "mopac " + filename
+}
+
+// ReadOut reads a Mopac output file and the auxiliary file written
+// alongside it and returns the heat of formation in Hartrees. The
+// output file is used to detect errors and completion, while the
+// energy is taken from the higher-precision aux file
 func (m Mopac) ReadOut(filename string) (result float64, err error) {
 	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 	if _, err = os.Stat(filename); os.IsNotExist(err) {
-		runtime.UnlockOSThread()
 		return brokenFloat, ErrFileNotFound
 	}
 	err = ErrEnergyNotFound
 	result = brokenFloat
-	// lines, _ := ReadFile(filename)
-	// TODO test if file lost by pbs
-	// TODO test for error
-	// TODO test for parse failing
-	// TODO test for finished but no energy
+	lines, _ := ReadFile(filename)
+	if len(lines) == 1 {
+		return result, ErrBlankOutput
+	}
+	done := false
+	for _, line := range lines {
+		upper := strings.ToUpper(line)
+		for _, e := range mopacErrors {
+			if strings.Contains(upper, e) {
+				return result, ErrFileContainsError
+			}
+		}
+		if strings.Contains(line, mopacDone) {
+			done = true
+		}
+	}
+	if !done {
+		return
+	}
+	// output finished, so missing energy in aux is an error
+	err = ErrFinishedButNoEnergy
+	auxfile := TrimExt(filename) + ".aux"
+	lines, _ = ReadFile(auxfile)
+	complete := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, mopacHeat) {
+			// Fortran double precision exponent
+			num := strings.Replace(line[len(mopacHeat):], "D", "E", 1)
+			result, err = strconv.ParseFloat(num, 64)
+			if err != nil {
+				return brokenFloat, ErrEnergyNotParsed
+			}
+			result /= kcalHartree
+		}
+		if strings.Contains(line, mopacAuxEnd) {
+			complete = true
+		}
+	}
+	// truncated aux file
+	if !complete {
+		return brokenFloat, ErrFinishedButNoEnergy
+	}
 	return
 }
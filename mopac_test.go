@@ -1,6 +1,7 @@
 package main
 
 import (
+	"math"
 	"reflect"
 	"testing"
 )
@@ -33,3 +34,34 @@ func TestMakeIn(t *testing.T) {
 		t.Errorf("got %q, wanted %q", got, want)
 	}
 }
+
+func TestReadMopacOut(t *testing.T) {
+	tests := []struct {
+		msg      string
+		filename string
+		want     float64
+		err      error
+	}{
+		{"energy in aux file", "testfiles/mopac.out", -57.7768582 / kcalHartree, nil},
+		{"no output file", "testfiles/mopac1.out", brokenFloat, ErrFileNotFound},
+		{"still running", "testfiles/mopacrunning.out", brokenFloat, ErrEnergyNotFound},
+		{"SCF failure", "testfiles/mopacerr.out", brokenFloat, ErrFileContainsError},
+		{"missing params.dat", "testfiles/mopacparams.out", brokenFloat, ErrFileContainsError},
+		{"truncated aux file", "testfiles/mopactrunc.out", brokenFloat, ErrFinishedButNoEnergy},
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			got, err := m.ReadOut(test.filename)
+			if err != test.err {
+				t.Errorf("got error %v, wanted %v", err, test.err)
+			}
+			if math.IsNaN(test.want) {
+				if !math.IsNaN(got) {
+					t.Errorf("got %v, wanted %v", got, test.want)
+				}
+			} else if math.Abs(got-test.want) > 1e-12 {
+				t.Errorf("got %v, wanted %v", got, test.want)
+			}
+		})
+	}
+}
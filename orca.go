@@ -0,0 +1,143 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	orcaEnergy     = "FINAL SINGLE POINT ENERGY"
+	orcaGradient   = "CARTESIAN GRADIENT"
+	orcaTerminated = "ORCA TERMINATED NORMALLY"
+)
+
+// orcaErrors are the strings indicating that an ORCA calculation
+// failed
+var orcaErrors = []string{
+	"ORCA finished by error termination",
+	"SCF NOT CONVERGED",
+	"ABORTING THE RUN",
+}
+
+// Orca implements the Program interface
+type Orca struct{}
+
+// MakeHead returns the header for an ORCA input file, asking for the
+// gradient as well with useGradients
+func (o Orca) MakeHead() []string {
+	keywords := "! " + orcaMethod + " " + basis + " TightSCF"
+	if useGradients {
+		keywords += " EnGrad"
+	}
+	return []string{keywords,
+		"* xyz " + charge + " " + Multiplicity()}
+}
+
+// MakeFoot returns the footer for an ORCA input file, closing the
+// geometry block
+func (o Orca) MakeFoot() []string {
+	return []string{"*"}
+}
+
+// MakeIn returns the contents of an ORCA input file
+func (o Orca) MakeIn(names []string, coords []float64) []string {
+	body := make([]string, 0)
+	for i := range names {
+		tmp := make([]string, 0)
+		tmp = append(tmp, names[i])
+		for _, c := range coords[3*i : 3*i+3] {
+			s := strconv.FormatFloat(c, 'f', 10, 64)
+			tmp = append(tmp, s)
+		}
+		body = append(body, strings.Join(tmp, " "))
+	}
+	if inputTemplate != nil {
+		return TemplateIn(body, orcaMethod)
+	}
+	return MakeInput(o.MakeHead(), o.MakeFoot(), body)
+}
+
+// WriteIn uses MakeIn to write an ORCA input file to filename
+func (o Orca) WriteIn(filename string, names []string, coords []float64) {
+	lines := o.MakeIn(names, coords)
+	writelines := strings.Join(lines, "\n")
+	err := ioutil.WriteFile(filename, []byte(writelines), 0755)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Command returns the command for running ORCA on filename. ORCA
+// writes to standard output, so it is redirected to the same name
+// with a .out extension
+func (o Orca) Command(filename string) string {
+	return "orca " + filename + " > " + TrimExt(filename) + ".out"
+}
+
+// ReadOut reads an ORCA output file and returns the final single
+// point energy
+func (o Orca) ReadOut(filename string) (result float64, err error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if _, err = os.Stat(filename); os.IsNotExist(err) {
+		return brokenFloat, ErrFileNotFound
+	}
+	err = ErrEnergyNotFound
+	result = brokenFloat
+	lines, _ := ReadFile(filename)
+	if len(lines) == 1 {
+		return result, ErrBlankOutput
+	}
+	for _, line := range lines {
+		for _, e := range orcaErrors {
+			if strings.Contains(line, e) {
+				return brokenFloat, ErrFileContainsError
+			}
+		}
+		if strings.HasPrefix(line, orcaEnergy) {
+			fields := strings.Fields(line)
+			result, err = strconv.ParseFloat(fields[len(fields)-1], 64)
+			if err != nil {
+				return brokenFloat, ErrEnergyNotParsed
+			}
+		}
+		if strings.Contains(line, orcaTerminated) && err != nil {
+			err = ErrFinishedButNoEnergy
+		}
+	}
+	return
+}
+
+// ReadGradient reads the Cartesian gradient from an ORCA output file
+func (o Orca) ReadGradient(filename string) ([]float64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return nil, ErrFileNotFound
+	}
+	lines, _ := ReadFile(filename)
+	if len(lines) == 1 {
+		return nil, ErrBlankOutput
+	}
+	finished := false
+	for _, line := range lines {
+		for _, e := range orcaErrors {
+			if strings.Contains(line, e) {
+				return nil, ErrFileContainsError
+			}
+		}
+		if strings.Contains(line, orcaTerminated) {
+			finished = true
+		}
+	}
+	if grad, ok := GradientBlock(lines, orcaGradient); ok {
+		return grad, nil
+	}
+	if finished {
+		return nil, ErrFinishedButNoEnergy
+	}
+	return nil, ErrGradientNotFound
+}
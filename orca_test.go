@@ -0,0 +1,79 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMakeOrcaIn(t *testing.T) {
+	want := []string{
+		"! CCSD(T) cc-pVTZ-F12 TightSCF",
+		"* xyz 0 1",
+		"H 0.0000000000 0.7574590974 0.5217905143",
+		"O 0.0000000000 0.0000000000 -0.0657441568",
+		"H 0.0000000000 -0.7574590974 0.5217905143",
+		"*"}
+	got := Orca{}.MakeIn(testnames, testcoords)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwanted %#v\n", got, want)
+	}
+}
+
+func TestReadOrcaOut(t *testing.T) {
+	tests := []struct {
+		msg      string
+		filename string
+		want     float64
+		err      error
+	}{
+		{"final single point energy", "testfiles/orca.out", -76.332116918350, nil},
+		{"no output file", "testfiles/orca1.out", brokenFloat, ErrFileNotFound},
+		{"error termination", "testfiles/orcaerr.out", brokenFloat, ErrFileContainsError},
+		{"finished but no energy", "testfiles/orcanoenergy.out", brokenFloat, ErrFinishedButNoEnergy},
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			got, err := Orca{}.ReadOut(test.filename)
+			if err != test.err {
+				t.Errorf("got error %v, wanted %v", err, test.err)
+			}
+			if math.IsNaN(test.want) {
+				if !math.IsNaN(got) {
+					t.Errorf("got %v, wanted %v", got, test.want)
+				}
+			} else if got != test.want {
+				t.Errorf("got %v, wanted %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadOrcaGradient(t *testing.T) {
+	tests := []struct {
+		msg      string
+		filename string
+		want     []float64
+		err      error
+	}{
+		{"cartesian gradient", "testfiles/orcagrad.out", []float64{
+			0, 0.006264396, -0.003728340,
+			0, 0, 0.007456681,
+			0, -0.006264396, -0.003728340,
+		}, nil},
+		{"no output file", "testfiles/orca1.out", nil, ErrFileNotFound},
+		{"error termination", "testfiles/orcaerr.out", nil, ErrFileContainsError},
+		{"finished but no gradient", "testfiles/orca.out", nil, ErrFinishedButNoEnergy},
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			got, err := Orca{}.ReadGradient(test.filename)
+			if err != test.err {
+				t.Errorf("got error %v, wanted %v", err, test.err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, wanted %v", got, test.want)
+			}
+		})
+	}
+}
@@ -5,12 +5,20 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 // PBS implements the Submission interface
 type PBS struct{}
 
+// pbsArrays holds the numbers of submitted PBS array jobs, which
+// qstat only recognizes with a trailing []
+var (
+	pbsArrays      = make(map[int]bool)
+	pbsArraysMutex sync.Mutex
+)
+
 // MakeHead makes a header for a PBS input file
 func (p PBS) MakeHead() []string {
 	return []string{"#!/bin/sh",
@@ -35,17 +43,38 @@ func (p PBS) MakeHead() []string {
 
 // MakeFoot makes a footer for a PBS input file
 func (p PBS) MakeFoot(Sig1 int, dump *GarbageHeap) []string {
-	sig1 := strconv.Itoa(Sig1)
-	return []string{"ssh -t maple pkill -" + sig1 + " " + progName,
-		strings.Join(dump.Dump(), "\n"), "rm -rf $TMPDIR"}
+	foot := []string{strings.Join(dump.Dump(), "\n"), "rm -rf $TMPDIR"}
+	if completion == "SIGNAL" {
+		sig1 := strconv.Itoa(Sig1)
+		foot = append([]string{"ssh -t maple pkill -" + sig1 + " " + progName}, foot...)
+	}
+	return foot
 }
 
 // Make calls MakeHead and MakeFoot to generate a PBS input file
 func (p PBS) Make(filename string, Sig1 int, dump *GarbageHeap) []string {
-	body := []string{"molpro -t 1 " + filename}
+	if jobTemplate != nil {
+		return MakeJob(filename, Sig1, dump)
+	}
+	body := []string{Prog.Command(filename)}
+	if completion == "WATCH" {
+		body = append(body, "touch "+DoneFile(filename))
+	}
 	return MakeInput(p.MakeHead(), p.MakeFoot(Sig1, dump), body)
 }
 
+// MakeArray returns the contents of a PBS array script with ntasks
+// subjobs, each running its line of indexfile
+func (p PBS) MakeArray(indexfile string, ntasks, Sig1 int, dump *GarbageHeap) []string {
+	head := p.MakeHead()
+	// directives have to come before the first command, so put it
+	// right after the job name
+	head = append(head[:2:2], append([]string{"#PBS -J 1-" + strconv.Itoa(ntasks)},
+		head[2:]...)...)
+	body := []string{`eval "$(sed -n "${PBS_ARRAY_INDEX}p" ` + indexfile + `)"`}
+	return MakeInput(head, p.MakeFoot(Sig1, dump), body)
+}
+
 // Write uses Make to write the contents of a PBS input file to
 // filename
 func (p PBS) Write(pbsfile, molprofile string, Sig1 int, dump *GarbageHeap) {
@@ -66,7 +95,89 @@ func (p PBS) Submit(filename string) int {
 		// just now adding -f to this one
 		out, err = exec.Command("qsub", "-f", filename).Output()
 	}
-	b := Basename(string(out))
-	i, _ := strconv.Atoi(b)
+	i := PBSJobID(string(out))
+	if strings.Contains(string(out), "[]") {
+		pbsArraysMutex.Lock()
+		pbsArrays[i] = true
+		pbsArraysMutex.Unlock()
+	}
 	return i
 }
+
+// PBSJobIDs returns ids as arguments for qstat and qdel
+func PBSJobIDs(ids []int) []string {
+	args := make([]string, 0, len(ids))
+	pbsArraysMutex.Lock()
+	defer pbsArraysMutex.Unlock()
+	for _, id := range ids {
+		if pbsArrays[id] {
+			args = append(args, strconv.Itoa(id)+"[]")
+		} else {
+			args = append(args, strconv.Itoa(id))
+		}
+	}
+	return args
+}
+
+// Cancel runs qdel on all of ids
+func (p PBS) Cancel(ids []int) error {
+	return exec.Command("qdel", PBSJobIDs(ids)...).Run()
+}
+
+// PBSJobID returns the number of the job from a PBS job ID like
+// 123.server, or 123[].server for an array job
+func PBSJobID(id string) int {
+	id = strings.TrimSpace(id)
+	if i := strings.IndexAny(id, ".["); i >= 0 {
+		id = id[:i]
+	}
+	i, _ := strconv.Atoi(id)
+	return i
+}
+
+// Status runs qstat once on all of ids and returns the state of each
+// job it reports. Jobs qstat no longer knows about are marked as
+// JobVanished
+func (p PBS) Status(ids []int) (map[int]JobState, error) {
+	args := append([]string{"-x"}, PBSJobIDs(ids)...)
+	// qstat exits nonzero if any of the ids are unknown, as they are
+	// once finished jobs leave its history
+	out, err := QueueOutput(exec.Command("qstat", args...), "Unknown Job Id")
+	if err != nil {
+		return nil, err
+	}
+	states := ParseQstat(string(out))
+	for _, id := range ids {
+		if _, ok := states[id]; !ok {
+			states[id] = JobVanished
+		}
+	}
+	return states, nil
+}
+
+// ParseQstat returns the state of each job in the output of qstat
+// -x. Array jobs are listed as 123[].server with state B while any
+// of their subjobs are running
+func ParseQstat(out string) map[int]JobState {
+	states := make(map[int]JobState)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 {
+			continue
+		}
+		id := PBSJobID(fields[0])
+		if id == 0 {
+			// header lines
+			continue
+		}
+		switch fields[4] {
+		case "Q", "H", "W", "T", "S":
+			states[id] = JobQueued
+		case "F", "X":
+			states[id] = JobDone
+		default:
+			states[id] = JobRunning
+		}
+	}
+	return states
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"io/ioutil"
 	"os"
 	"reflect"
 	"testing"
@@ -69,3 +70,62 @@ func TestQsubmit(t *testing.T) {
 		t.Errorf("got %d, wanted %d", got, want)
 	}
 }
+
+func TestMakePBSFootPoll(t *testing.T) {
+	defer func(c string) { completion = c }(completion)
+	completion = "POLL"
+	want := []string{"rm test1*\nrm test2*\nrm test3*", "rm -rf $TMPDIR"}
+	tdump := GarbageHeap{Heap: []string{"test1", "test2", "test3"}}
+	got := P.MakeFoot(5, &tdump)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, wanted %#v", got, want)
+	}
+}
+
+func TestParseQstat(t *testing.T) {
+	out, _ := ioutil.ReadFile("testfiles/qstat.out")
+	got := ParseQstat(string(out))
+	want := map[int]JobState{
+		1234: JobDone,
+		1235: JobRunning,
+		1236: JobQueued,
+		1237: JobQueued,
+		1240: JobRunning,
+		1241: JobDone,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+}
+
+func TestPBSJobID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want int
+	}{
+		{"1234.maple\n", 1234},
+		{"1240[].maple\n", 1240},
+		{"1240[3].maple", 1240},
+		{"Job", 0},
+	}
+	for _, test := range tests {
+		if got := PBSJobID(test.id); got != test.want {
+			t.Errorf("PBSJobID(%q): got %d, wanted %d", test.id, got, test.want)
+		}
+	}
+}
+
+func TestMakePBSArray(t *testing.T) {
+	tdump := GarbageHeap{Heap: []string{"test1"}}
+	got := P.MakeArray("inp/array.idx", 120, 35, &tdump)
+	want := append([]string{"#!/bin/sh", "#PBS -N go-cart", "#PBS -J 1-120"},
+		P.MakeHead()[2:]...)
+	want = append(want,
+		`eval "$(sed -n "${PBS_ARRAY_INDEX}p" inp/array.idx)"`,
+		"ssh -t maple pkill -35 go-cart",
+		"rm test1*",
+		"rm -rf $TMPDIR")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwanted %#v", got, want)
+	}
+}
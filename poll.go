@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Shared state for polling the Queue
+var (
+	pollInterval = 10 * time.Second
+	pollMutex    sync.Mutex
+	pollJobs     = make(map[int]chan JobState)
+	pollOnce     sync.Once
+)
+
+// pollRetries is the number of calls to Queue.Status in a row that
+// can fail before Poll gives up on the queue and lets every waiter go
+const pollRetries = 6
+
+// Poll checks the status of every job being waited on with a single
+// call to Queue.Status every pollInterval and hands the final state
+// of each finished job to its waiter. Jobs missing from the result
+// of Status are left alone until the next call. If Status keeps
+// failing, every waiter is told that its job vanished, so that it
+// checks its output and resubmits instead of waiting forever
+func Poll() {
+	var fails int
+	for {
+		pollMutex.Lock()
+		interval := pollInterval
+		pollMutex.Unlock()
+		time.Sleep(interval)
+		pollMutex.Lock()
+		ids := make([]int, 0, len(pollJobs))
+		for id := range pollJobs {
+			ids = append(ids, id)
+		}
+		pollMutex.Unlock()
+		if len(ids) == 0 {
+			continue
+		}
+		states, err := Queue.Status(ids)
+		if err != nil {
+			fails++
+			fmt.Println("error polling the queue for", err)
+			if fails < pollRetries {
+				continue
+			}
+			states = make(map[int]JobState, len(ids))
+			for _, id := range ids {
+				states[id] = JobVanished
+			}
+		}
+		fails = 0
+		pollMutex.Lock()
+		for id, state := range states {
+			if ch, ok := pollJobs[id]; ok && state.Finished() {
+				ch <- state
+				delete(pollJobs, id)
+			}
+		}
+		pollMutex.Unlock()
+	}
+}
+
+// PollWait waits for Poll to report that the job numbered num has
+// left the queue or for timeout to elapse. A job that times out
+// stays registered, so a later call picks up where this one left off
+func PollWait(num int, timeout time.Duration) error {
+	pollOnce.Do(func() { go Poll() })
+	pollMutex.Lock()
+	ch, ok := pollJobs[num]
+	if !ok {
+		ch = make(chan JobState, 1)
+		pollJobs[num] = ch
+	}
+	pollMutex.Unlock()
+	select {
+	case state := <-ch:
+		if state != JobDone {
+			fmt.Println("job ", num, " ended with state ", state)
+		}
+		return nil
+	case <-time.After(timeout):
+		return ErrTimeout
+	}
+}
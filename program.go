@@ -1,5 +1,7 @@
 package main
 
+import "strconv"
+
 // Program is an interface for quantum chemistry programs
 type Program interface {
 	MakeHead() []string
@@ -7,4 +9,24 @@ type Program interface {
 	MakeIn([]string, []float64) []string
 	WriteIn(string, []string, []float64)
 	ReadOut(string) (float64, error)
+	Command(string) string
+}
+
+// ScratchProgram is implemented by Programs that need a separate
+// working directory for each job, with fixed input and output file
+// names inside of it
+type ScratchProgram interface {
+	Program
+	InName() string
+	OutName() string
+}
+
+// Multiplicity returns the spin multiplicity, 2S+1, corresponding to
+// the Molpro-style spin input, which gives 2S
+func Multiplicity() string {
+	s, err := strconv.Atoi(spin)
+	if err != nil {
+		panic(err)
+	}
+	return strconv.Itoa(s + 1)
 }
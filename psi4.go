@@ -0,0 +1,154 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	psi4Exiting = "Psi4 exiting successfully"
+)
+
+// psi4Errors are the strings indicating that a Psi4 calculation
+// failed
+var psi4Errors = []string{
+	"PsiException",
+	"Traceback",
+	"Psi4 encountered an error",
+}
+
+// Psi4 implements the Program interface
+type Psi4 struct{}
+
+// MakeHead returns the header for a Psi4 input file
+func (p Psi4) MakeHead() []string {
+	return []string{"molecule {",
+		charge + " " + Multiplicity()}
+}
+
+// MakeFoot returns the footer for a Psi4 input file. The final
+// energy is read from the variables printed at the end, and with
+// useGradients the gradient is computed along with it
+func (p Psi4) MakeFoot() []string {
+	driver := "energy"
+	if useGradients {
+		driver = "gradient"
+	}
+	return []string{"units angstrom",
+		"no_com",
+		"no_reorient",
+		"symmetry c1",
+		"}",
+		"set basis " + basis,
+		"set e_convergence 10",
+		"set d_convergence 10",
+		driver + "('" + psi4Method + "')",
+		"print_variables()"}
+}
+
+// MakeIn returns the contents of a Psi4 input file
+func (p Psi4) MakeIn(names []string, coords []float64) []string {
+	body := make([]string, 0)
+	for i := range names {
+		tmp := make([]string, 0)
+		tmp = append(tmp, names[i])
+		for _, c := range coords[3*i : 3*i+3] {
+			s := strconv.FormatFloat(c, 'f', 10, 64)
+			tmp = append(tmp, s)
+		}
+		body = append(body, strings.Join(tmp, " "))
+	}
+	if inputTemplate != nil {
+		return TemplateIn(body, psi4Method)
+	}
+	return MakeInput(p.MakeHead(), p.MakeFoot(), body)
+}
+
+// WriteIn uses MakeIn to write a Psi4 input file to filename
+func (p Psi4) WriteIn(filename string, names []string, coords []float64) {
+	lines := p.MakeIn(names, coords)
+	writelines := strings.Join(lines, "\n")
+	err := ioutil.WriteFile(filename, []byte(writelines), 0755)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Command returns the command for running Psi4 on filename, with the
+// output written to the same name with a .out extension
+func (p Psi4) Command(filename string) string {
+	return "psi4 -n 1 " + filename + " " + TrimExt(filename) + ".out"
+}
+
+// ReadOut reads a Psi4 output file and returns the value of
+// psi4Variable from the printed variables
+func (p Psi4) ReadOut(filename string) (result float64, err error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if _, err = os.Stat(filename); os.IsNotExist(err) {
+		return brokenFloat, ErrFileNotFound
+	}
+	err = ErrEnergyNotFound
+	result = brokenFloat
+	lines, _ := ReadFile(filename)
+	if len(lines) == 1 {
+		return result, ErrBlankOutput
+	}
+	variable := `"` + psi4Variable + `"`
+	for _, line := range lines {
+		for _, e := range psi4Errors {
+			if strings.Contains(line, e) {
+				return brokenFloat, ErrFileContainsError
+			}
+		}
+		if strings.HasPrefix(line, variable) {
+			split := strings.Split(line, "=>")
+			if len(split) != 2 {
+				return brokenFloat, ErrEnergyNotParsed
+			}
+			result, err = strconv.ParseFloat(strings.TrimSpace(split[1]), 64)
+			if err != nil {
+				return brokenFloat, ErrEnergyNotParsed
+			}
+		}
+		if strings.Contains(line, psi4Exiting) && err != nil {
+			err = ErrFinishedButNoEnergy
+		}
+	}
+	return
+}
+
+// ReadGradient reads the last total gradient printed in a Psi4 output
+// file
+func (p Psi4) ReadGradient(filename string) ([]float64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return nil, ErrFileNotFound
+	}
+	lines, _ := ReadFile(filename)
+	if len(lines) == 1 {
+		return nil, ErrBlankOutput
+	}
+	finished := false
+	for _, line := range lines {
+		for _, e := range psi4Errors {
+			if strings.Contains(line, e) {
+				return nil, ErrFileContainsError
+			}
+		}
+		if strings.Contains(line, psi4Exiting) {
+			finished = true
+		}
+	}
+	if grad, ok := GradientBlock(lines, "-Total Gradient:"); ok {
+		return grad, nil
+	}
+	if finished {
+		return nil, ErrFinishedButNoEnergy
+	}
+	return nil, ErrGradientNotFound
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMakePsi4In(t *testing.T) {
+	want := []string{
+		"molecule {",
+		"0 1",
+		"H 0.0000000000 0.7574590974 0.5217905143",
+		"O 0.0000000000 0.0000000000 -0.0657441568",
+		"H 0.0000000000 -0.7574590974 0.5217905143",
+		"units angstrom",
+		"no_com",
+		"no_reorient",
+		"symmetry c1",
+		"}",
+		"set basis cc-pVTZ-F12",
+		"set e_convergence 10",
+		"set d_convergence 10",
+		"energy('ccsd(t)')",
+		"print_variables()"}
+	got := Psi4{}.MakeIn(testnames, testcoords)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwanted %#v\n", got, want)
+	}
+}
+
+func TestReadPsi4Out(t *testing.T) {
+	tests := []struct {
+		msg      string
+		filename string
+		variable string
+		want     float64
+		err      error
+	}{
+		{"current energy", "testfiles/psi4.out", "CURRENT ENERGY", -76.333586167595, nil},
+		{"chosen variable", "testfiles/psi4.out", "CCSD TOTAL ENERGY", -76.323842131082, nil},
+		{"no output file", "testfiles/psi41.out", "CURRENT ENERGY", brokenFloat, ErrFileNotFound},
+		{"SCF failure", "testfiles/psi4err.out", "CURRENT ENERGY", brokenFloat, ErrFileContainsError},
+		{"finished but no energy", "testfiles/psi4noenergy.out", "CURRENT ENERGY", brokenFloat,
+			ErrFinishedButNoEnergy},
+	}
+	temp := psi4Variable
+	defer func() { psi4Variable = temp }()
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			psi4Variable = test.variable
+			got, err := Psi4{}.ReadOut(test.filename)
+			if err != test.err {
+				t.Errorf("got error %v, wanted %v", err, test.err)
+			}
+			if math.IsNaN(test.want) {
+				if !math.IsNaN(got) {
+					t.Errorf("got %v, wanted %v", got, test.want)
+				}
+			} else if got != test.want {
+				t.Errorf("got %v, wanted %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadPsi4Gradient(t *testing.T) {
+	tests := []struct {
+		msg      string
+		filename string
+		want     []float64
+		err      error
+	}{
+		{"total gradient", "testfiles/psi4grad.out", []float64{
+			0, 0.006264396193, -0.003728340402,
+			0, 0, 0.007456680804,
+			0, -0.006264396193, -0.003728340402,
+		}, nil},
+		{"no output file", "testfiles/psi41.out", nil, ErrFileNotFound},
+		{"SCF failure", "testfiles/psi4err.out", nil, ErrFileContainsError},
+		{"finished but no gradient", "testfiles/psi4.out", nil, ErrFinishedButNoEnergy},
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			got, err := Psi4{}.ReadGradient(test.filename)
+			if err != test.err {
+				t.Errorf("got error %v, wanted %v", err, test.err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, wanted %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SGE implements the Submission interface for Son of Grid Engine
+// and other Grid Engine variants
+type SGE struct{}
+
+// MakeHead returns the header for an SGE input file
+func (g SGE) MakeHead() []string {
+	return []string{"#!/bin/sh",
+		"#$ -N go-cart",
+		"#$ -S /bin/bash",
+		"#$ -cwd",
+		"#$ -j y",
+		"#$ -o /dev/null",
+		"#$ -l h_rt=100:00:00",
+		"#$ -l h_vmem=9G"}
+}
+
+// MakeFoot returns the footer for an SGE input file. Grid Engine
+// sets SGE_O_HOST to the host the job was submitted from, so the
+// signal goes back there
+func (g SGE) MakeFoot(Sig1 int, dump *GarbageHeap) []string {
+	foot := []string{strings.Join(dump.Dump(), "\n")}
+	if completion == "SIGNAL" {
+		sig1 := strconv.Itoa(Sig1)
+		foot = append([]string{"ssh -t $SGE_O_HOST pkill -" + sig1 + " " + progName}, foot...)
+	}
+	return foot
+}
+
+// Make uses MakeHead and MakeFoot to return the contents of an SGE
+// input file
+func (g SGE) Make(filename string, Sig1 int, dump *GarbageHeap) []string {
+	if jobTemplate != nil {
+		return MakeJob(filename, Sig1, dump)
+	}
+	body := []string{Prog.Command(filename)}
+	if completion == "WATCH" {
+		body = append(body, "touch "+DoneFile(filename))
+	}
+	return MakeInput(g.MakeHead(), g.MakeFoot(Sig1, dump), body)
+}
+
+// MakeArray returns the contents of an SGE array script with ntasks
+// tasks, each running its line of indexfile, with at most
+// concRoutines running at once
+func (g SGE) MakeArray(indexfile string, ntasks, Sig1 int, dump *GarbageHeap) []string {
+	head := append(g.MakeHead(),
+		"#$ -t 1-"+strconv.Itoa(ntasks),
+		"#$ -tc "+strconv.Itoa(concRoutines))
+	body := []string{`eval "$(sed -n "${SGE_TASK_ID}p" ` + indexfile + `)"`}
+	return MakeInput(head, g.MakeFoot(Sig1, dump), body)
+}
+
+// Write uses Make to write the contents of an SGE input file to
+// filename
+func (g SGE) Write(pbsfile, molprofile string, Sig1 int, dump *GarbageHeap) {
+	lines := g.Make(molprofile, Sig1, dump)
+	writelines := strings.Join(lines, "\n")
+	err := ioutil.WriteFile(pbsfile, []byte(writelines), 0755)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Submit runs qsub -terse on filename, which prints only the job ID
+func (g SGE) Submit(filename string) int {
+	out, err := exec.Command("qsub", "-terse", filename).Output()
+	for err != nil {
+		time.Sleep(time.Second)
+		out, err = exec.Command("qsub", "-terse", filename).Output()
+	}
+	return SGEJobID(string(out))
+}
+
+// SGEJobID returns the number of the job from the output of qsub
+// -terse, which is 123 for a single job or 123.1-10:1 for an array
+func SGEJobID(id string) int {
+	id = strings.TrimSpace(id)
+	if i := strings.Index(id, "."); i >= 0 {
+		id = id[:i]
+	}
+	i, _ := strconv.Atoi(id)
+	return i
+}
+
+// Cancel runs qdel on all of ids
+func (g SGE) Cancel(ids []int) error {
+	args := make([]string, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, strconv.Itoa(id))
+	}
+	return exec.Command("qdel", args...).Run()
+}
+
+// Status runs qstat once for all of the user's jobs and returns the
+// state of each of ids. Finished jobs drop out of qstat, so jobs it
+// does not list are marked as JobVanished
+func (g SGE) Status(ids []int) (map[int]JobState, error) {
+	out, err := QueueOutput(exec.Command("qstat", "-u", os.Getenv("USER")))
+	if err != nil {
+		return nil, err
+	}
+	all := ParseSGEStat(string(out))
+	states := make(map[int]JobState)
+	for _, id := range ids {
+		if state, ok := all[id]; ok {
+			states[id] = state
+		} else {
+			states[id] = JobVanished
+		}
+	}
+	return states, nil
+}
+
+// ParseSGEStat returns the state of each job in the output of Grid
+// Engine's qstat. Array tasks are listed separately under the same
+// job ID, and the array as a whole takes the state of its least
+// finished task
+func ParseSGEStat(out string) map[int]JobState {
+	states := make(map[int]JobState)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		id, err := strconv.Atoi(fields[0])
+		if err != nil {
+			// header lines
+			continue
+		}
+		var state JobState
+		code := fields[4]
+		switch {
+		case strings.Contains(code, "E"), strings.Contains(code, "d"):
+			state = JobFailed
+		case strings.Contains(code, "q"), strings.Contains(code, "h"),
+			strings.Contains(code, "s"), strings.Contains(code, "S"):
+			state = JobQueued
+		default:
+			state = JobRunning
+		}
+		if old, ok := states[id]; !ok || state < old {
+			states[id] = state
+		}
+	}
+	return states
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestMakeSGE(t *testing.T) {
+	want := []string{
+		"#!/bin/sh",
+		"#$ -N go-cart",
+		"#$ -S /bin/bash",
+		"#$ -cwd",
+		"#$ -j y",
+		"#$ -o /dev/null",
+		"#$ -l h_rt=100:00:00",
+		"#$ -l h_vmem=9G",
+		"molpro -t 1 molpro.in",
+		"ssh -t $SGE_O_HOST pkill -35 go-cart",
+		"rm test1*"}
+	tdump := GarbageHeap{Heap: []string{"test1"}}
+	got := SGE{}.Make("molpro.in", 35, &tdump)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwanted %#v", got, want)
+	}
+}
+
+func TestSGEJobID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want int
+	}{
+		{"5101\n", 5101},
+		{"5200.1-10:1\n", 5200},
+		{"", 0},
+	}
+	for _, test := range tests {
+		if got := SGEJobID(test.id); got != test.want {
+			t.Errorf("SGEJobID(%q): got %d, wanted %d", test.id, got, test.want)
+		}
+	}
+}
+
+func TestParseSGEStat(t *testing.T) {
+	out, _ := ioutil.ReadFile("testfiles/sgeqstat.out")
+	got := ParseSGEStat(string(out))
+	want := map[int]JobState{
+		5101: JobRunning,
+		5102: JobQueued,
+		5103: JobFailed,
+		5104: JobFailed,
+		5200: JobQueued,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"io/ioutil"
+	"os"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -26,18 +28,48 @@ func (s Slurm) MakeHead() []string {
 
 // MakeFoot returns the footer for a Slurm input file
 func (s Slurm) MakeFoot(Sig1 int, dump *GarbageHeap) []string {
-	sig1 := strconv.Itoa(Sig1)
-	return []string{"ssh -t master pkill -" + sig1 + " " + progName,
-		strings.Join(dump.Dump(), "\n")}
+	foot := []string{strings.Join(dump.Dump(), "\n")}
+	if completion == "SIGNAL" {
+		sig1 := strconv.Itoa(Sig1)
+		foot = append([]string{"ssh -t master pkill -" + sig1 + " " + progName}, foot...)
+	}
+	return foot
+}
+
+// Command returns the command for running Prog on filename, which
+// differs from Prog.Command only for Molpro
+func (s Slurm) Command(filename string) string {
+	// Molpro is run through a wrapper script on our Slurm cluster
+	switch Prog.(type) {
+	case Molpro, CcCR:
+		return "/home/qc/bin/molpro2018.sh 1 1 " + filename
+	}
+	return Prog.Command(filename)
 }
 
 // Make uses MakeHead and MakeFoot to return the contents of a Slurm
 // input file
 func (s Slurm) Make(filename string, Sig1 int, dump *GarbageHeap) []string {
-	body := []string{"/home/qc/bin/molpro2018.sh 1 1 " + filename}
+	if jobTemplate != nil {
+		return MakeJob(filename, Sig1, dump)
+	}
+	body := []string{s.Command(filename)}
+	if completion == "WATCH" {
+		body = append(body, "touch "+DoneFile(filename))
+	}
 	return MakeInput(s.MakeHead(), s.MakeFoot(Sig1, dump), body)
 }
 
+// MakeArray returns the contents of a Slurm array script with ntasks
+// tasks, each running its line of indexfile, with at most
+// concRoutines running at once
+func (s Slurm) MakeArray(indexfile string, ntasks, Sig1 int, dump *GarbageHeap) []string {
+	head := append(s.MakeHead(),
+		fmt.Sprintf("#SBATCH --array=1-%d%%%d", ntasks, concRoutines))
+	body := []string{`eval "$(sed -n "${SLURM_ARRAY_TASK_ID}p" ` + indexfile + `)"`}
+	return MakeInput(head, s.MakeFoot(Sig1, dump), body)
+}
+
 // Write uses Make to write the contents of a Slurm input file to
 // filename
 func (s Slurm) Write(pbsfile, molprofile string, Sig1 int, dump *GarbageHeap) {
@@ -58,7 +90,74 @@ func (s Slurm) Submit(filename string) int {
 		time.Sleep(time.Second)
 		out, err = exec.Command("sbatch", filename).Output()
 	}
-	b := Basename(string(out))
-	i, _ := strconv.Atoi(b)
+	// sbatch prints "Submitted batch job 123"
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return 0
+	}
+	i, _ := strconv.Atoi(fields[len(fields)-1])
 	return i
 }
+
+// Cancel runs scancel on all of ids
+func (s Slurm) Cancel(ids []int) error {
+	args := make([]string, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, strconv.Itoa(id))
+	}
+	return exec.Command("scancel", args...).Run()
+}
+
+// Status runs squeue once for all of the user's jobs and returns the
+// state of each of ids. Jobs squeue no longer knows about are marked
+// as JobVanished
+func (s Slurm) Status(ids []int) (map[int]JobState, error) {
+	out, err := QueueOutput(exec.Command("squeue", "-h", "-o", "%i %t",
+		"-u", os.Getenv("USER")))
+	if err != nil {
+		return nil, err
+	}
+	all := ParseSqueue(string(out))
+	states := make(map[int]JobState)
+	for _, id := range ids {
+		if state, ok := all[id]; ok {
+			states[id] = state
+		} else {
+			states[id] = JobVanished
+		}
+	}
+	return states, nil
+}
+
+// ParseSqueue returns the state of each job in the output of squeue
+// -h -o "%i %t". The tasks of a job array are listed as 123_4, or
+// 123_[5-9] while pending, and the array as a whole takes the state
+// of its least finished task
+func ParseSqueue(out string) map[int]JobState {
+	states := make(map[int]JobState)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		id, err := strconv.Atoi(strings.Split(fields[0], "_")[0])
+		if err != nil {
+			continue
+		}
+		var state JobState
+		switch fields[1] {
+		case "PD", "CF", "RQ", "RF", "RH", "RS", "S", "ST":
+			state = JobQueued
+		case "CD":
+			state = JobDone
+		case "F", "CA", "TO", "NF", "OOM", "BF", "DL", "PR", "RV":
+			state = JobFailed
+		default:
+			state = JobRunning
+		}
+		if old, ok := states[id]; !ok || state < old {
+			states[id] = state
+		}
+	}
+	return states
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestMakeSlurmFoot(t *testing.T) {
+	tests := []struct {
+		completion string
+		want       []string
+	}{
+		{"SIGNAL", []string{"ssh -t master pkill -35 go-cart", "rm test1*"}},
+		{"POLL", []string{"rm test1*"}},
+	}
+	defer func(c string) { completion = c }(completion)
+	for _, test := range tests {
+		t.Run(test.completion, func(t *testing.T) {
+			completion = test.completion
+			tdump := GarbageHeap{Heap: []string{"test1"}}
+			got := Slurm{}.MakeFoot(35, &tdump)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, wanted %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSqueue(t *testing.T) {
+	out, _ := ioutil.ReadFile("testfiles/squeue.out")
+	got := ParseSqueue(string(out))
+	want := map[int]JobState{
+		4301: JobDone,
+		4302: JobRunning,
+		4303: JobQueued,
+		4304: JobFailed,
+		4305: JobRunning,
+		4306: JobFailed,
+		4400: JobQueued,
+		4401: JobDone,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+}
+
+func TestMakeSlurmArray(t *testing.T) {
+	defer func(c int) { concRoutines = c }(concRoutines)
+	concRoutines = 50
+	want := []string{
+		"#!/bin/bash",
+		"#SBATCH --job-name=go-cart",
+		"#SBATCH --ntasks=1",
+		"#SBATCH --cpus-per-task=1",
+		"#SBATCH -o /dev/null",
+		"#SBATCH --no-requeue",
+		"#SBATCH --mem=9gb",
+		"#SBATCH --array=1-120%50",
+		`eval "$(sed -n "${SLURM_ARRAY_TASK_ID}p" inp/array.idx)"`,
+		"ssh -t master pkill -35 go-cart",
+		"rm test1*"}
+	tdump := GarbageHeap{Heap: []string{"test1"}}
+	got := Slurm{}.MakeArray("inp/array.idx", 120, 35, &tdump)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwanted %#v", got, want)
+	}
+}
+
+func TestWriteArray(t *testing.T) {
+	defer func(q Submission, c string) { Queue, completion = q, c }(Queue, completion)
+	Queue = Slurm{}
+	completion = "WATCH"
+	dir := t.TempDir()
+	var tdump GarbageHeap
+	WriteArray(Slurm{}, dir+"/array.pbs", dir+"/array.idx",
+		[]string{"inp/job1.inp", "inp/job2.inp"}, 35, &tdump)
+	got, _ := ReadFile(dir + "/array.idx")
+	want := []string{
+		"/home/qc/bin/molpro2018.sh 1 1 inp/job1.inp; touch inp/job1.done",
+		"/home/qc/bin/molpro2018.sh 1 1 inp/job2.inp; touch inp/job2.done"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwanted %#v", got, want)
+	}
+}
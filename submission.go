@@ -1,5 +1,13 @@
 package main
 
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
 // Submission is an interface for queueing systems
 type Submission interface {
 	MakeHead() []string
@@ -7,4 +15,75 @@ type Submission interface {
 	Make(filename string, Sig1 int, dump *GarbageHeap) []string
 	Write(pbsfile, molprofile string, Sig1 int, dump *GarbageHeap)
 	Submit(filename string) int
+	Status(ids []int) (map[int]JobState, error)
+	Cancel(ids []int) error
+}
+
+// Waiter is implemented by Submissions that can report the
+// completion of a job directly instead of through real-time signals
+type Waiter interface {
+	Wait(num int, timeout time.Duration) error
+}
+
+// ArraySubmission is implemented by Submissions that can run many
+// inputs as a single job array. Task i of the array runs line i of
+// indexfile
+type ArraySubmission interface {
+	MakeArray(indexfile string, ntasks, Sig1 int, dump *GarbageHeap) []string
+}
+
+// JobCommand returns the command for running Prog on filename in a
+// job script for Queue
+func JobCommand(filename string) string {
+	if s, ok := Queue.(Slurm); ok {
+		return s.Command(filename)
+	}
+	return Prog.Command(filename)
+}
+
+// JobState is the state of a submitted job as reported by Status
+type JobState int
+
+// States reported by Status
+const (
+	JobQueued JobState = iota
+	JobRunning
+	JobDone
+	JobFailed
+	JobVanished // no longer known to the queue
+)
+
+func (j JobState) String() string {
+	return [...]string{
+		"JobQueued",
+		"JobRunning",
+		"JobDone",
+		"JobFailed",
+		"JobVanished",
+	}[j]
+}
+
+// Finished reports whether a job in state j has left the queue
+func (j JobState) Finished() bool {
+	return j >= JobDone
+}
+
+// QueueOutput runs the queue command cmd and returns its standard
+// output. A nonzero exit is only an error if its standard error does
+// not contain any of benign, the messages it also exits nonzero with
+// when it simply has no jobs to report
+func QueueOutput(cmd *exec.Cmd, benign ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err == nil {
+		return out, nil
+	}
+	for _, b := range benign {
+		if strings.Contains(stderr.String(), b) {
+			return out, nil
+		}
+	}
+	return out, fmt.Errorf("%s: %v: %s", cmd.Args[0], err,
+		strings.TrimSpace(stderr.String()))
 }
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// symTol is the largest distance in Angstroms between an atom moved
+// by a symmetry operation and the atom it lands on
+const symTol = 1e-4
+
+// SymOp is a symmetry operation of the input geometry that sends
+// each Cartesian axis to another axis, possibly reversed, like the
+// reflections and twofold rotations of a molecule whose symmetry
+// elements lie along the axes. These are the only operations that
+// can be used: one mixing the axes, like the threefold rotation of
+// ammonia or the sixfold rotation of benzene, sends a step along one
+// axis to a step along no axis, which is not one of the displaced
+// geometries, so it is left out
+type SymOp struct {
+	Axes  [3]int // the axis each axis is sent to
+	Signs [3]int // +1 or -1 for each axis
+	Atoms []int  // the atom each atom is sent to
+}
+
+// symOps holds the operations used by EnergyKey, which are only
+// found when symmetry is requested
+var symOps []SymOp
+
+// SignedPermutations returns the 48 operations sending the Cartesian
+// axes to each other, without their Atoms
+func SignedPermutations() []SymOp {
+	perms := [][3]int{
+		{0, 1, 2}, {0, 2, 1}, {1, 0, 2},
+		{1, 2, 0}, {2, 0, 1}, {2, 1, 0},
+	}
+	ops := make([]SymOp, 0, 48)
+	for _, p := range perms {
+		for s := 0; s < 8; s++ {
+			signs := [3]int{1, 1, 1}
+			for i := range signs {
+				if s&(1<<i) != 0 {
+					signs[i] = -1
+				}
+			}
+			ops = append(ops, SymOp{Axes: p, Signs: signs})
+		}
+	}
+	return ops
+}
+
+// Det returns the determinant of op, +1 for proper rotations and -1
+// for improper ones
+func (op SymOp) Det() int {
+	det := op.Signs[0] * op.Signs[1] * op.Signs[2]
+	// each pair of axes out of order flips the parity
+	for i := range op.Axes {
+		for j := i + 1; j < len(op.Axes); j++ {
+			if op.Axes[i] > op.Axes[j] {
+				det = -det
+			}
+		}
+	}
+	return det
+}
+
+// Trace returns the trace of op as a matrix
+func (op SymOp) Trace() int {
+	tr := 0
+	for i := range op.Axes {
+		if op.Axes[i] == i {
+			tr += op.Signs[i]
+		}
+	}
+	return tr
+}
+
+// FindAxisSymmetry returns the operations in SignedPermutations that
+// send the geometry given by names and coords onto itself, acting
+// about its centroid, with their Atoms filled in. This is not the
+// whole point group of the geometry, only the part of it that keeps
+// the axes
+func FindAxisSymmetry(names []string, coords []float64, tol float64) []SymOp {
+	natoms := len(names)
+	var center [3]float64
+	for a := 0; a < natoms; a++ {
+		for x := 0; x < 3; x++ {
+			center[x] += coords[3*a+x] / float64(natoms)
+		}
+	}
+	ops := make([]SymOp, 0)
+OPS:
+	for _, op := range SignedPermutations() {
+		op.Atoms = make([]int, natoms)
+		for a := 0; a < natoms; a++ {
+			var moved [3]float64
+			for x := 0; x < 3; x++ {
+				moved[op.Axes[x]] = center[op.Axes[x]] +
+					float64(op.Signs[x])*(coords[3*a+x]-center[x])
+			}
+			found := false
+			for b := 0; b < natoms; b++ {
+				if names[b] != names[a] {
+					continue
+				}
+				var d float64
+				for x := 0; x < 3; x++ {
+					d += math.Pow(moved[x]-coords[3*b+x], 2)
+				}
+				if math.Sqrt(d) < tol {
+					op.Atoms[a] = b
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue OPS
+			}
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// Orient returns the geometry given by names and coords rotated about
+// its centroid into the frame where FindAxisSymmetry finds the most
+// operations. The frames tried are those of the principal axes of the
+// geometry and, where the principal axes are degenerate, ones with
+// axes through atoms or between pairs of like atoms, which is where
+// the symmetry elements lie. coords itself is returned unless another
+// frame does better, so a geometry whose symmetry elements already lie
+// along the axes is left alone, and whether it was rotated is reported
+func Orient(names []string, coords []float64, tol float64) ([]float64, bool) {
+	natoms := len(names)
+	var center [3]float64
+	for a := 0; a < natoms; a++ {
+		for x := 0; x < 3; x++ {
+			center[x] += coords[3*a+x] / float64(natoms)
+		}
+	}
+	r := make([][3]float64, natoms)
+	var m [3][3]float64
+	for a := range r {
+		for x := 0; x < 3; x++ {
+			r[a][x] = coords[3*a+x] - center[x]
+		}
+		for x := 0; x < 3; x++ {
+			for y := 0; y < 3; y++ {
+				m[x][y] += r[a][x] * r[a][y]
+			}
+		}
+	}
+	vals, vecs := symEigen(m)
+	frames := [][3][3]float64{vecs}
+	// directions through atoms and between pairs of like atoms
+	// the same distance from the center
+	var dirs [][3]float64
+	for a := range r {
+		dirs = append(dirs, r[a])
+		for b := a + 1; b < natoms; b++ {
+			if names[a] == names[b] && math.Abs(norm(r[a])-norm(r[b])) < tol {
+				dirs = append(dirs, add(r[a], r[b]))
+			}
+		}
+	}
+	degen := func(i, j int) bool {
+		return math.Abs(vals[i]-vals[j]) < tol*(1+vals[2])
+	}
+	switch {
+	case degen(0, 1) && degen(1, 2):
+		dirs = unitDirs(dirs, tol)
+		for _, d := range dirs {
+			for _, e := range dirs {
+				if math.Abs(dot(d, e)) < tol {
+					frames = append(frames, [3][3]float64{d, e, cross(d, e)})
+				}
+			}
+		}
+	case degen(0, 1) || degen(1, 2):
+		// the axis that is not degenerate
+		u := vecs[0]
+		if degen(0, 1) {
+			u = vecs[2]
+		}
+		for i, d := range dirs {
+			dirs[i] = add(d, scale(u, -dot(d, u)))
+		}
+		for _, d := range unitDirs(dirs, tol) {
+			frames = append(frames, [3][3]float64{d, cross(u, d), u})
+		}
+	}
+	best, rotated := coords, false
+	most := len(FindAxisSymmetry(names, coords, tol))
+	for _, f := range frames {
+		if most == 48 {
+			break
+		}
+		moved := make([]float64, len(coords))
+		for a := range r {
+			for x := 0; x < 3; x++ {
+				moved[3*a+x] = center[x] + dot(f[x], r[a])
+			}
+		}
+		if n := len(FindAxisSymmetry(names, moved, tol)); n > most {
+			best, most, rotated = moved, n, true
+		}
+	}
+	return best, rotated
+}
+
+// unitDirs returns the directions in dirs normalized, leaving out
+// ones too short to have a direction and repeats, including ones
+// pointing the opposite way
+func unitDirs(dirs [][3]float64, tol float64) [][3]float64 {
+	var units [][3]float64
+DIRS:
+	for _, d := range dirs {
+		n := norm(d)
+		if n < tol {
+			continue
+		}
+		d = scale(d, 1/n)
+		for _, u := range units {
+			if math.Abs(dot(d, u)) > 1-tol {
+				continue DIRS
+			}
+		}
+		units = append(units, d)
+	}
+	return units
+}
+
+// symEigen returns the eigenvalues of the symmetric matrix m in
+// increasing order, along with the corresponding unit eigenvectors
+// as rows, which form a proper rotation. It uses Jacobi rotations
+func symEigen(m [3][3]float64) (vals [3]float64, vecs [3][3]float64) {
+	v := [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	for sweep := 0; sweep < 50; sweep++ {
+		off := m[0][1]*m[0][1] + m[0][2]*m[0][2] + m[1][2]*m[1][2]
+		if off < 1e-30 {
+			break
+		}
+		for p := 0; p < 2; p++ {
+			for q := p + 1; q < 3; q++ {
+				if m[p][q] == 0 {
+					continue
+				}
+				theta := (m[q][q] - m[p][p]) / (2 * m[p][q])
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < 3; k++ {
+					mkp, mkq := m[k][p], m[k][q]
+					m[k][p], m[k][q] = c*mkp-s*mkq, s*mkp+c*mkq
+				}
+				for k := 0; k < 3; k++ {
+					mpk, mqk := m[p][k], m[q][k]
+					m[p][k], m[q][k] = c*mpk-s*mqk, s*mpk+c*mqk
+				}
+				for k := 0; k < 3; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p], v[k][q] = c*vkp-s*vkq, s*vkp+c*vkq
+				}
+			}
+		}
+	}
+	order := []int{0, 1, 2}
+	sort.Slice(order, func(i, j int) bool {
+		return m[order[i]][order[i]] < m[order[j]][order[j]]
+	})
+	for i, k := range order {
+		vals[i] = m[k][k]
+		for x := 0; x < 3; x++ {
+			vecs[i][x] = v[x][k]
+		}
+	}
+	if dot(cross(vecs[0], vecs[1]), vecs[2]) < 0 {
+		vecs[2] = scale(vecs[2], -1)
+	}
+	return
+}
+
+func dot(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func norm(a [3]float64) float64 {
+	return math.Sqrt(dot(a, a))
+}
+
+func add(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] + b[0], a[1] + b[1], a[2] + b[2]}
+}
+
+func scale(a [3]float64, f float64) [3]float64 {
+	return [3]float64{f * a[0], f * a[1], f * a[2]}
+}
+
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+// AxisGroup returns the Schoenflies symbol of the group made up of
+// ops, identified by how many of each kind of operation it has. For
+// the ops from FindAxisSymmetry, this is the largest subgroup of the
+// point group that keeps the axes, like D2h for benzene
+func AxisGroup(ops []SymOp) string {
+	// kinds of operation by determinant and trace
+	var count struct{ C2, C3, C4, I, Sigma, S4, S6 int }
+	for _, op := range ops {
+		switch det, tr := op.Det(), op.Trace(); {
+		case det == 1 && tr == -1:
+			count.C2++
+		case det == 1 && tr == 0:
+			count.C3++
+		case det == 1 && tr == 1:
+			count.C4++
+		case det == -1 && tr == -3:
+			count.I++
+		case det == -1 && tr == 1:
+			count.Sigma++
+		case det == -1 && tr == -1:
+			count.S4++
+		case det == -1 && tr == 0:
+			count.S6++
+		}
+	}
+	groups := []struct {
+		name                         string
+		c2, c3, c4, i, sigma, s4, s6 int
+	}{
+		{"C1", 0, 0, 0, 0, 0, 0, 0},
+		{"Cs", 0, 0, 0, 0, 1, 0, 0},
+		{"Ci", 0, 0, 0, 1, 0, 0, 0},
+		{"C2", 1, 0, 0, 0, 0, 0, 0},
+		{"C3", 0, 2, 0, 0, 0, 0, 0},
+		{"C2v", 1, 0, 0, 0, 2, 0, 0},
+		{"C2h", 1, 0, 0, 1, 1, 0, 0},
+		{"D2", 3, 0, 0, 0, 0, 0, 0},
+		{"C4", 1, 0, 2, 0, 0, 0, 0},
+		{"S4", 1, 0, 0, 0, 0, 2, 0},
+		{"C3v", 0, 2, 0, 0, 3, 0, 0},
+		{"D3", 3, 2, 0, 0, 0, 0, 0},
+		{"S6", 0, 2, 0, 1, 0, 0, 2},
+		{"D2h", 3, 0, 0, 1, 3, 0, 0},
+		{"C4v", 1, 0, 2, 0, 4, 0, 0},
+		{"D4", 5, 0, 2, 0, 0, 0, 0},
+		{"C4h", 1, 0, 2, 1, 1, 2, 0},
+		{"D2d", 3, 0, 0, 0, 2, 2, 0},
+		{"T", 3, 8, 0, 0, 0, 0, 0},
+		{"D3d", 3, 2, 0, 1, 3, 0, 2},
+		{"D4h", 5, 0, 2, 1, 5, 2, 0},
+		{"Td", 3, 8, 0, 0, 6, 6, 0},
+		{"Th", 3, 8, 0, 1, 3, 0, 8},
+		{"O", 9, 8, 6, 0, 0, 0, 0},
+		{"Oh", 9, 8, 6, 1, 9, 6, 8},
+	}
+	for _, g := range groups {
+		if count.C2 == g.c2 && count.C3 == g.c3 && count.C4 == g.c4 &&
+			count.I == g.i && count.Sigma == g.sigma &&
+			count.S4 == g.s4 && count.S6 == g.s6 {
+			return g.name
+		}
+	}
+	return "order " + strconv.Itoa(len(ops))
+}
+
+// MoveCoord returns the zero-based Cartesian coordinate that op sends
+// coordinate c to, along with the sign it picks up
+func (op SymOp) MoveCoord(c int) (int, int) {
+	atom, axis := c/3, c%3
+	return 3*op.Atoms[atom] + op.Axes[axis], op.Signs[axis]
+}
+
+// Apply returns the steps reaching the geometry that op sends the
+// geometry reached by steps to
+func (op SymOp) Apply(steps []int) []int {
+	moved := make([]int, len(steps))
+	for i, v := range steps {
+		sign := 1
+		if v < 0 {
+			sign = -1
+			v = -v
+		}
+		c, s := op.MoveCoord(v - 1)
+		moved[i] = sign * s * (c + 1)
+	}
+	return moved
+}
+
+// KeyOp returns EnergyKey(steps) along with the operation sending the
+// geometry reached by steps to the geometry with that key, which is
+// nil when they are the same
+func KeyOp(steps []int) (string, *SymOp) {
+	key := StepKey(steps)
+	var op *SymOp
+	for i := range symOps {
+		if k := StepKey(symOps[i].Apply(steps)); k < key {
+			key = k
+			op = &symOps[i]
+		}
+	}
+	return key, op
+}
+
+// EnergyKey returns the key under which the energy of the geometry
+// reached by steps is stored. Without symmetry this is its StepKey,
+// and with it the smallest StepKey of any geometry equivalent to it
+// by one of symOps, so that equivalent geometries share one energy
+func EnergyKey(steps []int) string {
+	key, _ := KeyOp(steps)
+	return key
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"math"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+// benzene returns benzene in the xy plane with a carbon on the x axis
+func benzene() (names []string, coords []float64) {
+	for _, atom := range []struct {
+		name string
+		r    float64
+	}{{"C", 1.397}, {"H", 2.481}} {
+		for k := 0; k < 6; k++ {
+			theta := float64(k) * math.Pi / 3
+			names = append(names, atom.name)
+			coords = append(coords,
+				atom.r*math.Cos(theta), atom.r*math.Sin(theta), 0)
+		}
+	}
+	return
+}
+
+// methane returns methane with its hydrogens on alternate corners of
+// a cube
+func methane() ([]string, []float64) {
+	return []string{"C", "H", "H", "H", "H"},
+		[]float64{0, 0, 0, 0.63, 0.63, 0.63, -0.63, -0.63, 0.63,
+			-0.63, 0.63, -0.63, 0.63, -0.63, -0.63}
+}
+
+func TestFindSymmetry(t *testing.T) {
+	benzNames, benzCoords := benzene()
+	methNames, methCoords := methane()
+	tests := []struct {
+		msg    string
+		names  []string
+		coords []float64
+		want   string
+	}{
+		{"water", testnames, testcoords, "C2v"},
+		{"benzene", benzNames, benzCoords, "D2h"},
+		{"methane", methNames, methCoords, "Td"},
+		{"hydroxyl", []string{"O", "H"},
+			[]float64{0, 0, 0, 0, 0.1, 1.0}, "Cs"},
+		{"bent", []string{"H", "O", "H"},
+			[]float64{0.1, 0.75, 0.5, 0, 0, 0, 0, -0.76, 0.5}, "C1"},
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			got := AxisGroup(FindAxisSymmetry(test.names, test.coords, symTol))
+			if got != test.want {
+				t.Errorf("got %s, wanted %s", got, test.want)
+			}
+		})
+	}
+}
+
+// tumble returns coords turned about all three axes and moved away
+// from the origin
+func tumble(coords []float64) []float64 {
+	a, b, c := 0.3, 1.1, -0.7
+	rot := [3][3]float64{
+		{math.Cos(a), -math.Sin(a), 0},
+		{math.Sin(a), math.Cos(a), 0},
+		{0, 0, 1},
+	}
+	for _, r := range [][3][3]float64{
+		{{1, 0, 0}, {0, math.Cos(b), -math.Sin(b)}, {0, math.Sin(b), math.Cos(b)}},
+		{{math.Cos(c), 0, math.Sin(c)}, {0, 1, 0}, {-math.Sin(c), 0, math.Cos(c)}},
+	} {
+		var prod [3][3]float64
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				for k := 0; k < 3; k++ {
+					prod[i][j] += r[i][k] * rot[k][j]
+				}
+			}
+		}
+		rot = prod
+	}
+	moved := make([]float64, len(coords))
+	for a := 0; a < len(coords)/3; a++ {
+		for x := 0; x < 3; x++ {
+			moved[3*a+x] = 0.5 * float64(x+1)
+			for y := 0; y < 3; y++ {
+				moved[3*a+x] += rot[x][y] * coords[3*a+y]
+			}
+		}
+	}
+	return moved
+}
+
+func TestOrient(t *testing.T) {
+	benzNames, benzCoords := benzene()
+	methNames, methCoords := methane()
+	tests := []struct {
+		msg     string
+		names   []string
+		coords  []float64
+		rotated bool
+		want    string
+	}{
+		{"aligned water", testnames, testcoords, false, "C2v"},
+		{"water", testnames, tumble(testcoords), true, "C2v"},
+		{"benzene", benzNames, tumble(benzCoords), true, "D2h"},
+		{"methane", methNames, tumble(methCoords), true, "Td"},
+		{"hydroxyl", []string{"O", "H"},
+			[]float64{0, 0, 0, 0, 0.1, 1.0}, true, "C4v"},
+		{"ammonia", []string{"N", "H", "H", "H"}, tumble([]float64{
+			0, 0, 0.38,
+			0.94, 0, 0,
+			-0.47, 0.814064, 0,
+			-0.47, -0.814064, 0,
+		}), true, "Cs"},
+		{"bent", []string{"H", "O", "H"},
+			[]float64{0.1, 0.75, 0.5, 0, 0, 0, 0, -0.76, 0.5}, true, "Cs"},
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			coords, rotated := Orient(test.names, test.coords, symTol)
+			if rotated != test.rotated {
+				t.Errorf("got rotated %v, wanted %v", rotated, test.rotated)
+			}
+			if !rotated && &coords[0] != &test.coords[0] {
+				t.Error("got a copy of coords, wanted coords itself")
+			}
+			got := AxisGroup(FindAxisSymmetry(test.names, coords, symTol))
+			if got != test.want {
+				t.Errorf("got %s, wanted %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDet(t *testing.T) {
+	for _, op := range SignedPermutations() {
+		var m [3][3]float64
+		for x := 0; x < 3; x++ {
+			m[op.Axes[x]][x] = float64(op.Signs[x])
+		}
+		want := dot(m[0], cross(m[1], m[2]))
+		if got := op.Det(); float64(got) != want {
+			t.Errorf("%v %v: got %d, wanted %v", op.Axes, op.Signs, got, want)
+		}
+	}
+}
+
+func TestEnergyKey(t *testing.T) {
+	defer func(s []SymOp) { symOps = s }(symOps)
+	symOps = FindAxisSymmetry(testnames, testcoords, symTol)
+	tests := []struct {
+		msg  string
+		a, b []int
+	}{
+		{"reflection", []int{1}, []int{-1}},
+		{"rotation", []int{2, 3}, []int{-8, 9}},
+		{"rotation and reflection", []int{2, 2, 4}, []int{-8, -8, 4}},
+		{"reference", nil, []int{1, -1}},
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			a, b := EnergyKey(test.a), EnergyKey(test.b)
+			if a != b {
+				t.Errorf("got %q and %q, wanted them equal", a, b)
+			}
+		})
+	}
+	t.Run("distinct", func(t *testing.T) {
+		// moving a hydrogen along the bond is not moving it across
+		if a, b := EnergyKey([]int{2}), EnergyKey([]int{-2}); a == b {
+			t.Errorf("got %q for both", a)
+		}
+	})
+	t.Run("without symmetry", func(t *testing.T) {
+		symOps = nil
+		if got := EnergyKey([]int{-1}); got != "-1" {
+			t.Errorf("got %q, wanted %q", got, "-1")
+		}
+	})
+}
+
+func TestSymmetryTotalJobs(t *testing.T) {
+	defer func(s []SymOp) { symOps = s }(symOps)
+	symOps = FindAxisSymmetry(testnames, testcoords, symTol)
+	full := []int{162, 1158, 5640}
+	for nd := 2; nd <= 4; nd++ {
+		got := TotalJobs(nd, 9)
+		if got >= full[nd-2] || got < full[nd-2]/len(symOps) {
+			t.Errorf("%d: got %d jobs, wanted between %d and %d",
+				nd, got, full[nd-2]/len(symOps), full[nd-2])
+		}
+	}
+}
+
+// TestAnalyticSymmetry checks that a force field computed with
+// symmetry for water matches one computed without it, from energies,
+// gradients, and Hessians
+func TestAnalyticSymmetry(t *testing.T) {
+	defer func(g, h bool) { useGradients, useHessians = g, h }(useGradients, useHessians)
+	for _, mode := range []string{"energy", "gradient", "hessian"} {
+		t.Run(mode, func(t *testing.T) {
+			useGradients = mode == "gradient"
+			useHessians = mode == "hessian"
+			testAnalyticSymmetry(t)
+		})
+	}
+}
+
+func testAnalyticSymmetry(t *testing.T) {
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(t.TempDir())
+	defer func(p Program, q Submission, n, c int, f func([]string, []float64) float64, s []SymOp) {
+		Prog, Queue, nDerivative, checkAfter, analyticPotential, symOps = p, q, n, c, f, s
+	}(Prog, Queue, nDerivative, checkAfter, analyticPotential, symOps)
+	Prog = Analytic{}
+	Queue = Local{}
+	nDerivative = 3
+	checkAfter = 0
+	var calls int64
+	analyticPotential = func(names []string, coords []float64) float64 {
+		atomic.AddInt64(&calls, 1)
+		return Morse(names, coords)
+	}
+	os.Mkdir("inp", 0755)
+	ncoords := len(testcoords)
+	run := func() (fc2s [][]float64, fc3s []float64) {
+		calls = 0
+		resetRun(ncoords)
+		var dump GarbageHeap
+		E0 := RefEnergy(testnames, testcoords, &dump)
+		RunJobs(testnames, testcoords, &dump, E0)
+		return fc2, fc3
+	}
+	symOps = nil
+	want2, want3 := run()
+	symOps = FindAxisSymmetry(testnames, testcoords, symTol)
+	got2, got3 := run()
+	if want := int64(TotalJobs(nDerivative, ncoords) + 1); calls != want {
+		t.Errorf("got %d energies, wanted %d", calls, want)
+	}
+	const eps = 1e-10
+	for i := range want2 {
+		for j := range want2[i] {
+			if math.Abs(got2[i][j]-want2[i][j]) > eps {
+				t.Errorf("fc2 %d %d: got %v, wanted %v",
+					i, j, got2[i][j], want2[i][j])
+			}
+		}
+	}
+	for i := range want3 {
+		if math.Abs(got3[i]-want3[i]) > eps {
+			t.Errorf("fc3 %d: got %v, wanted %v", i, got3[i], want3[i])
+		}
+	}
+}
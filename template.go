@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"text/template"
+)
+
+// inputTemplate replaces the MakeHead and MakeFoot of the Program when
+// it is set by the template keyword
+var inputTemplate *template.Template
+
+// InputData holds the values available to input templates
+type InputData struct {
+	Geom   string // one line per atom, as the Program writes them
+	Charge string
+	Spin   string
+	Mult   string
+	Method string
+	Basis  string
+}
+
+// TemplateIn executes inputTemplate with the geometry lines in body
+// and method, returning the lines of the resulting input file
+func TemplateIn(body []string, method string) []string {
+	var b strings.Builder
+	err := inputTemplate.Execute(&b, InputData{
+		Geom:   strings.Join(body, "\n"),
+		Charge: charge,
+		Spin:   spin,
+		Mult:   Multiplicity(),
+		Method: method,
+		Basis:  basis,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return strings.Split(strings.TrimRight(b.String(), "\n"), "\n")
+}
+
+// jobTemplate replaces the job script built by the Submission when it
+// is set by the jobtemplate keyword
+var jobTemplate *template.Template
+
+// JobData holds the values available to job-script templates
+type JobData struct {
+	Input    string // name of the input file
+	Command  string // command the Program uses to run Input
+	Signal   int
+	ProgName string // name of this program, for pkill
+	Done     string // sentinel file to create when completion is WATCH
+	Cleanup  string // one rm command per line
+}
+
+// MakeJob executes jobTemplate for the job running filename and
+// signaling Sig1 when it finishes, returning the lines of the
+// resulting job script
+func MakeJob(filename string, Sig1 int, dump *GarbageHeap) []string {
+	return ExecJob(JobData{
+		Input:    filename,
+		Command:  JobCommand(filename),
+		Signal:   Sig1,
+		ProgName: progName,
+		Done:     DoneFile(filename),
+		Cleanup:  strings.Join(dump.Dump(), "\n"),
+	})
+}
+
+// ExecJob executes jobTemplate with data and returns the lines of the
+// resulting job script
+func ExecJob(data JobData) []string {
+	var b strings.Builder
+	err := jobTemplate.Execute(&b, data)
+	if err != nil {
+		panic(err)
+	}
+	return strings.Split(strings.TrimRight(b.String(), "\n"), "\n")
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"text/template"
+)
+
+func TestTemplateIn(t *testing.T) {
+	defer func() { inputTemplate = nil }()
+	inputTemplate = template.Must(template.ParseFiles("testfiles/molpro.tmpl"))
+	want := []string{
+		"memory,500,m",
+		"geomtyp=xyz",
+		"angstrom",
+		"geometry={",
+		"H 0.0000000000 0.7574590974 0.5217905143",
+		"O 0.0000000000 0.0000000000 -0.0657441568",
+		"H 0.0000000000 -0.7574590974 0.5217905143",
+		"}",
+		"basis=cc-pVTZ-F12",
+		"set,charge=0",
+		"set,spin=0",
+		"{df-hf}",
+		"{CCSD(T)-F12}"}
+	got := Molpro{}.MakeIn(testnames, testcoords)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwanted %#v\n", got, want)
+	}
+}
+
+func TestMakeJob(t *testing.T) {
+	defer func() { jobTemplate = nil }()
+	jobTemplate = template.Must(template.ParseFiles("testfiles/job.tmpl"))
+	want := []string{
+		"#!/bin/sh",
+		"#SBATCH --job-name=go-cart",
+		"#SBATCH --time=24:00:00",
+		"module load molpro",
+		"molpro -t 1 molpro.in",
+		"ssh -t login pkill -35 " + progName,
+		"rm test1*",
+		"rm test2*",
+		"rm test3*"}
+	for _, q := range []Submission{PBS{}, Slurm{}, Local{}} {
+		tdump := GarbageHeap{Heap: []string{"test1", "test2", "test3"}}
+		got := q.Make("molpro.in", 35, &tdump)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %#v\nwanted %#v\n", got, want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"path"
+	"sync"
+	"time"
+)
+
+// ErrWatchUnsupported is returned by Watch on systems without inotify
+var ErrWatchUnsupported = errors.New("Watch completion requires inotify")
+
+// Shared state for watching inp/
+var (
+	watchTimeout = 10 * time.Minute
+	watchMutex   sync.Mutex
+	watchDone    = make(map[string]bool)
+	watchWaiters = make(map[string]chan struct{})
+)
+
+// DoneFile returns the name of the sentinel file the job script for
+// the input file infile creates when it finishes
+func DoneFile(infile string) string {
+	if _, ok := Prog.(ScratchProgram); ok {
+		return path.Dir(infile) + ".done"
+	}
+	return TrimExt(infile) + ".done"
+}
+
+// WatchName returns the name of the job that has finished if file is
+// its sentinel and false otherwise. Only the sentinel counts, since
+// an output file can be closed more than once and before the job is
+// really over
+func WatchName(file string) (string, bool) {
+	if path.Ext(file) == ".done" {
+		return TrimExt(file), true
+	}
+	return "", false
+}
+
+// MarkDone wakes the waiter for the job called name or, if nobody is
+// waiting yet, latches it so the next WatchWait returns immediately
+func MarkDone(name string) {
+	watchMutex.Lock()
+	defer watchMutex.Unlock()
+	if ch, ok := watchWaiters[name]; ok {
+		close(ch)
+		delete(watchWaiters, name)
+		return
+	}
+	watchDone[name] = true
+}
+
+// ClearDone forgets any latched completion of the job called name, so
+// that a resubmitted job is not taken as finished before it runs and
+// jobs that finished without a waiter do not pile up
+func ClearDone(name string) {
+	watchMutex.Lock()
+	delete(watchDone, name)
+	watchMutex.Unlock()
+}
+
+// MarkAllDone wakes every waiter, for use when events may have been
+// lost
+func MarkAllDone() {
+	watchMutex.Lock()
+	defer watchMutex.Unlock()
+	for name, ch := range watchWaiters {
+		close(ch)
+		delete(watchWaiters, name)
+	}
+}
+
+// WatchWait waits for the job called name to be marked done or for
+// timeout to elapse
+func WatchWait(name string, timeout time.Duration) error {
+	return WatchWaitAny([]string{name}, timeout)
+}
+
+// WatchWaitAny waits for any of the jobs called names to be marked
+// done or for timeout to elapse
+func WatchWaitAny(names []string, timeout time.Duration) error {
+	watchMutex.Lock()
+	chans := make(map[string]chan struct{}, len(names))
+	for _, name := range names {
+		if watchDone[name] {
+			delete(watchDone, name)
+			watchMutex.Unlock()
+			return nil
+		}
+		ch, ok := watchWaiters[name]
+		if !ok {
+			ch = make(chan struct{})
+			watchWaiters[name] = ch
+		}
+		chans[name] = ch
+	}
+	watchMutex.Unlock()
+	// stop waiting when done, so that a later MarkDone latches
+	// instead of waking nobody
+	defer func() {
+		watchMutex.Lock()
+		for name, ch := range chans {
+			if watchWaiters[name] == ch {
+				delete(watchWaiters, name)
+			}
+		}
+		watchMutex.Unlock()
+	}()
+	woken := make(chan struct{}, len(chans))
+	stop := make(chan struct{})
+	defer close(stop)
+	for _, ch := range chans {
+		go func(ch chan struct{}) {
+			select {
+			case <-ch:
+				woken <- struct{}{}
+			case <-stop:
+			}
+		}(ch)
+	}
+	select {
+	case <-woken:
+		return nil
+	case <-time.After(timeout):
+		return ErrTimeout
+	}
+}
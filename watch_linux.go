@@ -0,0 +1,57 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// Watch starts a goroutine marking jobs done as inotify reports
+// their sentinel files in dir being created
+func Watch(dir string) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		return err
+	}
+	_, err = syscall.InotifyAddWatch(fd, dir,
+		syscall.IN_CREATE|syscall.IN_MOVED_TO)
+	if err != nil {
+		syscall.Close(fd)
+		return err
+	}
+	go func() {
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			n, err := syscall.Read(fd, buf)
+			if err == syscall.EINTR {
+				continue
+			}
+			if err != nil {
+				fmt.Println("stopped watching", dir, "for", err)
+				MarkAllDone()
+				return
+			}
+			for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+				ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+				start := off + syscall.SizeofInotifyEvent
+				off = start + int(ev.Len)
+				if ev.Mask&syscall.IN_Q_OVERFLOW != 0 {
+					MarkAllDone()
+					continue
+				}
+				file := string(buf[start:off])
+				// the name is padded with NULs
+				for len(file) > 0 && file[len(file)-1] == 0 {
+					file = file[:len(file)-1]
+				}
+				if name, ok := WatchName(file); ok {
+					MarkDone(name)
+				}
+			}
+		}
+	}()
+	return nil
+}
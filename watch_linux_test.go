@@ -0,0 +1,23 @@
+package main
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	if err := Watch(dir); err != nil {
+		t.Fatal(err)
+	}
+	// finishing the output alone does not mark the job done
+	ioutil.WriteFile(dir+"/watched.out", []byte("energy= -1.0\n"), 0755)
+	if err := WatchWait("watched", 100*time.Millisecond); err != ErrTimeout {
+		t.Errorf("output: got %v, wanted %v", err, ErrTimeout)
+	}
+	ioutil.WriteFile(dir+"/watched.done", nil, 0755)
+	if err := WatchWait("watched", 5*time.Second); err != nil {
+		t.Errorf("sentinel: got %v, wanted nil", err)
+	}
+}
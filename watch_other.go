@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+// Watch is not available without inotify
+func Watch(dir string) error {
+	return ErrWatchUnsupported
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchName(t *testing.T) {
+	tests := []struct {
+		file string
+		name string
+		ok   bool
+	}{
+		{"job1.done", "job1", true},
+		{"job1.out", "", false},
+		{"job1.inp", "", false},
+		{"job1.pbs", "", false},
+	}
+	for _, test := range tests {
+		name, ok := WatchName(test.file)
+		if name != test.name || ok != test.ok {
+			t.Errorf("WatchName(%q): got %q, %v, wanted %q, %v",
+				test.file, name, ok, test.name, test.ok)
+		}
+	}
+}
+
+func TestDoneFile(t *testing.T) {
+	defer func(p Program) { Prog = p }(Prog)
+	Prog = Molpro{}
+	if got := DoneFile("inp/job1.inp"); got != "inp/job1.done" {
+		t.Errorf("got %q, wanted %q", got, "inp/job1.done")
+	}
+	Prog = CFour{}
+	if got := DoneFile("inp/job1/ZMAT"); got != "inp/job1.done" {
+		t.Errorf("got %q, wanted %q", got, "inp/job1.done")
+	}
+}
+
+func TestWatchWait(t *testing.T) {
+	t.Run("latched", func(t *testing.T) {
+		MarkDone("latched")
+		if err := WatchWait("latched", time.Millisecond); err != nil {
+			t.Errorf("got %v, wanted nil", err)
+		}
+		if err := WatchWait("latched", time.Millisecond); err != ErrTimeout {
+			t.Errorf("got %v, wanted %v", err, ErrTimeout)
+		}
+	})
+	t.Run("resubmitted", func(t *testing.T) {
+		defer func(q Submission) { Queue = q }(Queue)
+		Queue = Local{}
+		MarkDone("resubmitted")
+		Forget(Submit("inp/resubmitted.pbs"))
+		if err := WatchWait("resubmitted", time.Millisecond); err != ErrTimeout {
+			t.Errorf("got %v, wanted %v", err, ErrTimeout)
+		}
+	})
+	t.Run("any", func(t *testing.T) {
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			MarkDone("second")
+		}()
+		if err := WatchWaitAny([]string{"first", "second"}, 5*time.Second); err != nil {
+			t.Errorf("got %v, wanted nil", err)
+		}
+		// the one still waiting latches instead of being lost
+		MarkDone("first")
+		if err := WatchWait("first", time.Millisecond); err != nil {
+			t.Errorf("got %v, wanted nil", err)
+		}
+	})
+	t.Run("waiting", func(t *testing.T) {
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			MarkDone("waiting")
+		}()
+		if err := WatchWait("waiting", 5*time.Second); err != nil {
+			t.Errorf("got %v, wanted nil", err)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	xtbEnergy   = "| TOTAL ENERGY"
+	xtbAbnormal = "abnormal termination of xtb"
+	xtbNormal   = "normal termination of xtb"
+)
+
+// XTB implements the Program and ScratchProgram interfaces for the
+// xtb semiempirical tight-binding program. xtb writes several files
+// with fixed names to the current directory, so each job is run in
+// its own directory
+type XTB struct{}
+
+// InName returns the name of the xyz file read by xtb
+func (x XTB) InName() string {
+	return "geom.xyz"
+}
+
+// OutName returns the name xtb output is written to
+func (x XTB) OutName() string {
+	return "xtb.out"
+}
+
+// MakeHead returns the comment line of an xyz file
+func (x XTB) MakeHead() []string {
+	return []string{"go-cart"}
+}
+
+// MakeFoot returns the empty footer for an xyz file
+func (x XTB) MakeFoot() []string {
+	return []string{}
+}
+
+// MakeIn returns the contents of an xyz file for xtb
+func (x XTB) MakeIn(names []string, coords []float64) []string {
+	body := make([]string, 0)
+	for i := range names {
+		tmp := make([]string, 0)
+		tmp = append(tmp, names[i])
+		for _, c := range coords[3*i : 3*i+3] {
+			s := strconv.FormatFloat(c, 'f', 10, 64)
+			tmp = append(tmp, s)
+		}
+		body = append(body, strings.Join(tmp, " "))
+	}
+	head := append([]string{strconv.Itoa(len(names))}, x.MakeHead()...)
+	return MakeInput(head, x.MakeFoot(), body)
+}
+
+// WriteIn uses MakeIn to write an xyz file to filename, creating its
+// scratch directory first
+func (x XTB) WriteIn(filename string, names []string, coords []float64) {
+	err := os.MkdirAll(path.Dir(filename), 0755)
+	if err != nil {
+		panic(err)
+	}
+	lines := x.MakeIn(names, coords)
+	writelines := strings.Join(lines, "\n")
+	err = ioutil.WriteFile(filename, []byte(writelines), 0755)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Command returns the command for running xtb in the directory
+// containing filename. The method is given as GFN0, GFN1, GFN2, or
+// GFNFF, and the tightest accuracy is requested to keep the finite
+// differences smooth
+func (x XTB) Command(filename string) string {
+	method := "--gfn " + strings.TrimPrefix(xtbMethod, "GFN")
+	if xtbMethod == "GFNFF" {
+		method = "--gfnff"
+	}
+	return "(cd " + path.Dir(filename) + " && xtb " + x.InName() + " " +
+		method + " --acc 0.0001 --chrg " + charge + " --uhf " + spin +
+		" > " + x.OutName() + ")"
+}
+
+// ReadOut reads an xtb output file and returns the total energy,
+// falling back to the energy file written alongside it
+func (x XTB) ReadOut(filename string) (result float64, err error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if _, err = os.Stat(filename); os.IsNotExist(err) {
+		return brokenFloat, ErrFileNotFound
+	}
+	err = ErrEnergyNotFound
+	result = brokenFloat
+	lines, _ := ReadFile(filename)
+	if len(lines) == 1 {
+		return result, ErrBlankOutput
+	}
+	finished := false
+	for _, line := range lines {
+		if strings.Contains(line, "[ERROR]") ||
+			strings.Contains(line, xtbAbnormal) {
+			return brokenFloat, ErrFileContainsError
+		}
+		if strings.HasPrefix(line, xtbEnergy) {
+			fields := strings.Fields(line[len(xtbEnergy):])
+			if len(fields) == 0 {
+				return brokenFloat, ErrEnergyNotParsed
+			}
+			result, err = strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return brokenFloat, ErrEnergyNotParsed
+			}
+		}
+		if strings.Contains(line, xtbNormal) {
+			finished = true
+		}
+	}
+	if err == ErrEnergyNotFound {
+		result, err = xtbEnergyFile(path.Join(path.Dir(filename), "energy"))
+	}
+	if err == ErrEnergyNotFound && finished {
+		err = ErrFinishedButNoEnergy
+	}
+	return
+}
+
+// xtbEnergyFile reads the total energy from the Turbomole-style
+// energy file written by xtb
+func xtbEnergyFile(filename string) (float64, error) {
+	lines, err := ReadFile(filename)
+	if err != nil {
+		return brokenFloat, ErrEnergyNotFound
+	}
+	for i, line := range lines {
+		if line == "$energy" && i+1 < len(lines) {
+			fields := strings.Fields(lines[i+1])
+			if len(fields) < 2 {
+				return brokenFloat, ErrEnergyNotParsed
+			}
+			f, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return brokenFloat, ErrEnergyNotParsed
+			}
+			return f, nil
+		}
+	}
+	return brokenFloat, ErrEnergyNotFound
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMakeXTBIn(t *testing.T) {
+	want := []string{
+		"3",
+		"go-cart",
+		"H 0.0000000000 0.7574590974 0.5217905143",
+		"O 0.0000000000 0.0000000000 -0.0657441568",
+		"H 0.0000000000 -0.7574590974 0.5217905143"}
+	got := XTB{}.MakeIn(testnames, testcoords)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwanted %#v\n", got, want)
+	}
+}
+
+func TestXTBCommand(t *testing.T) {
+	got := XTB{}.Command("inp/job/geom.xyz")
+	want := "(cd inp/job && xtb geom.xyz --gfn 2 --acc 0.0001 " +
+		"--chrg 0 --uhf 0 > xtb.out)"
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestReadXTBOut(t *testing.T) {
+	tests := []struct {
+		msg      string
+		filename string
+		want     float64
+		err      error
+	}{
+		{"total energy", "testfiles/xtb/xtb.out", -5.070544440612, nil},
+		{"energy file", "testfiles/xtbenergy/xtb.out", -5.070544440612, nil},
+		{"no output file", "testfiles/xtb1/xtb.out", brokenFloat, ErrFileNotFound},
+		{"abnormal termination", "testfiles/xtberr/xtb.out", brokenFloat, ErrFileContainsError},
+	}
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			got, err := XTB{}.ReadOut(test.filename)
+			if err != test.err {
+				t.Errorf("got error %v, wanted %v", err, test.err)
+			}
+			if math.IsNaN(test.want) {
+				if !math.IsNaN(got) {
+					t.Errorf("got %v, wanted %v", got, test.want)
+				}
+			} else if got != test.want {
+				t.Errorf("got %v, wanted %v", got, test.want)
+			}
+		})
+	}
+}